@@ -12,10 +12,23 @@ type Config struct {
 	Test      bool   `envconfig:"test" default:"false"`
 	Domain    string `envconfig:"domain" default:"/go/src/github.com/guilyx/go-pddl/data/domain.pddl"`
 	Problem   string `envconfig:"problem" default:"/go/src/github.com/guilyx/go-pddl/data/problem.pddl"`
-	MaxPeek   int    `envconfig:"max_peek" default:"2"`
+	Plan      string `envconfig:"plan"`
+	Report    bool   `envconfig:"report" default:"false"`
 	PrintPddl bool   `envconfig:"print_pddl" default:"false"`
+
+	// Identifiers is the normalization policy of the names of the files,
+	// IdentifiersPreserve or IdentifiersLower.
+	Identifiers string `envconfig:"identifiers" default:"preserve"`
 }
 
+const (
+	// IdentifiersPreserve keeps names as they are spelled.
+	IdentifiersPreserve = "preserve"
+	// IdentifiersLower lower-cases names, so that they match whatever
+	// their case, as PDDL is case-insensitive.
+	IdentifiersLower = "lower"
+)
+
 func NewConfig() (*Config, error) {
 	config := Config{}
 	err := envconfig.Process("", &config)
@@ -28,5 +41,8 @@ func NewConfig() (*Config, error) {
 	if len(config.Domain) < 1 {
 		return nil, fmt.Errorf("Domain file isn't parse-able")
 	}
+	if config.Identifiers != IdentifiersPreserve && config.Identifiers != IdentifiersLower {
+		return nil, fmt.Errorf("Unknown identifiers policy %s, expected %s or %s", config.Identifiers, IdentifiersPreserve, IdentifiersLower)
+	}
 	return &config, nil
 }
@@ -0,0 +1,370 @@
+package cst
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/guilyx/go-pddl/src/lexer"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+type TriviaKind int
+
+const (
+	Whitespace TriviaKind = iota
+	Comment
+)
+
+// Trivia is text without meaning for the grammar: whitespace, or a
+// comment up to the end of its line.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
+
+type Kind int
+
+const (
+	Atom Kind = iota
+	List
+)
+
+// Node is an atom or a parenthesized list of the concrete syntax tree.
+// With its trivia it keeps the exact text it was read from.
+type Node struct {
+	Kind Kind
+	// Token is the lexical token of an atom, or the opening parenthesis
+	// of a list, and Close the closing parenthesis of a list.
+	Token *lexer.ScannedToken
+	Close *lexer.ScannedToken
+	// Leading is the trivia before the atom or the opening parenthesis.
+	Leading  []*Trivia
+	Children []*Node
+	// Closing is the trivia before the closing parenthesis of a list.
+	Closing []*Trivia
+	Parent  *Node
+	// Start and End delimit the node in the text it was read from; they
+	// are -1 for nodes created by an edit.
+	Start int
+	End   int
+	Line  int
+}
+
+// Tree is the concrete syntax tree of a file.
+type Tree struct {
+	Name     string
+	Nodes    []*Node
+	Trailing []*Trivia
+	// EOF is the token ending the text.
+	EOF *lexer.ScannedToken
+}
+
+// splitTrivia cuts the text between two tokens into whitespace and
+// comments.
+func splitTrivia(s string) []*Trivia {
+	ts := []*Trivia{}
+	for len(s) > 0 {
+		var n int
+		var k TriviaKind
+		if s[0] == ';' {
+			k = Comment
+			n = strings.IndexByte(s, '\n')
+			if n < 0 {
+				n = len(s)
+			}
+		} else {
+			k = Whitespace
+			n = strings.IndexFunc(s, func(r rune) bool {
+				return !unicode.IsSpace(r)
+			})
+			if n < 0 {
+				n = len(s)
+			}
+		}
+		ts = append(ts, &Trivia{
+			Kind: k,
+			Text: s[:n],
+		})
+		s = s[n:]
+	}
+	return ts
+}
+
+// Read builds the concrete syntax tree of a PDDL text.
+func Read(name string, text string) (*Tree, error) {
+	lx, err := lexer.NewLexer(name, text)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %v", name, err)
+	}
+	return ReadLexer(lx)
+}
+
+// ReadLexer builds the concrete syntax tree of the text of the lexer,
+// which must not have been scanned yet. The text of a lexer over a
+// reader is read with a Stream instead.
+func ReadLexer(lx *lexer.Lexer) (*Tree, error) {
+	if lx.Streamed() {
+		return nil, fmt.Errorf("Failed to read %s: text is streamed", lx.Name)
+	}
+	name, text := lx.Name, lx.Text
+	t := &Tree{
+		Name: name,
+	}
+	var stack []*Node
+	add := func(n *Node) {
+		if len(stack) == 0 {
+			t.Nodes = append(t.Nodes, n)
+			return
+		}
+		top := stack[len(stack)-1]
+		n.Parent = top
+		top.Children = append(top.Children, n)
+	}
+	prev := 0
+	for {
+		tk, err := lx.ScanToken()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s: %v", name, err)
+		}
+		// The text of an error token is its message, not the source.
+		if tk.Type == lexer.TOKEN_ERROR {
+			msg := fmt.Sprintf("%s:%d: Failed to read: %s", name, tk.Line, tk.Text)
+			if q := lx.Quote(tk); q != "" {
+				msg += "\n" + q
+			}
+			return nil, fmt.Errorf("%s", msg)
+		}
+		trivia := splitTrivia(text[prev:tk.Position])
+		prev = tk.Position + len(tk.Text)
+		switch tk.Type {
+		case lexer.TOKEN_EOF:
+			if len(stack) > 0 {
+				open := stack[len(stack)-1]
+				return nil, fmt.Errorf("%s:%d: Failed to read: missing ) for the list opened here", name, open.Line)
+			}
+			t.Trailing = trivia
+			t.EOF = tk
+			return t, nil
+		case lexer.TOKEN_OPEN:
+			n := &Node{
+				Kind:     List,
+				Token:    tk,
+				Leading:  trivia,
+				Children: []*Node{},
+				Start:    tk.Position,
+				Line:     tk.Line,
+			}
+			add(n)
+			stack = append(stack, n)
+		case lexer.TOKEN_CLOSE:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%s:%d: Failed to read: unbalanced )", name, tk.Line)
+			}
+			n := stack[len(stack)-1]
+			n.Close = tk
+			n.Closing = trivia
+			n.End = prev
+			stack = stack[:len(stack)-1]
+		default:
+			add(&Node{
+				Kind:    Atom,
+				Token:   tk,
+				Leading: trivia,
+				Start:   tk.Position,
+				End:     prev,
+				Line:    tk.Line,
+			})
+		}
+	}
+}
+
+// Tokens returns the tokens of the tree in reading order, parentheses
+// included, ending with the EOF token.
+func (t *Tree) Tokens() []*lexer.ScannedToken {
+	tks := []*lexer.ScannedToken{}
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		tks = append(tks, n.Token)
+		if n.Kind == Atom {
+			return
+		}
+		for _, c := range n.Children {
+			visit(c)
+		}
+		tks = append(tks, n.Close)
+	}
+	for _, n := range t.Nodes {
+		visit(n)
+	}
+	return append(tks, t.EOF)
+}
+
+// ReadNode builds a single node from a text fragment, e.g. to insert
+// it in a tree.
+func ReadNode(text string) (*Node, error) {
+	t, err := Read("fragment", text)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Nodes) != 1 {
+		return nil, fmt.Errorf("Failed to read node: %d nodes in %q", len(t.Nodes), text)
+	}
+	n := t.Nodes[0]
+	n.forget()
+	return n, nil
+}
+
+// forget clears the spans of a node that doesn't belong to the text
+// it was read from anymore.
+func (n *Node) forget() {
+	n.Start, n.End = -1, -1
+	for _, c := range n.Children {
+		c.forget()
+	}
+}
+
+func toStringTrivia(ts []*Trivia) string {
+	var s string
+	for _, t := range ts {
+		s += t.Text
+	}
+	return s
+}
+
+// ToString returns the text of the node, with its leading trivia.
+func (n *Node) ToString() string {
+	var sb strings.Builder
+	n.write(&sb)
+	return sb.String()
+}
+
+func (n *Node) write(sb *strings.Builder) {
+	sb.WriteString(toStringTrivia(n.Leading))
+	if n.Kind == Atom {
+		sb.WriteString(n.Token.Text)
+		return
+	}
+	sb.WriteString("(")
+	for _, c := range n.Children {
+		c.write(sb)
+	}
+	sb.WriteString(toStringTrivia(n.Closing))
+	sb.WriteString(")")
+}
+
+// ToString returns the text of the tree, identical to the text it was
+// read from unless the tree was edited.
+func (t *Tree) ToString() string {
+	var sb strings.Builder
+	for _, n := range t.Nodes {
+		n.write(&sb)
+	}
+	sb.WriteString(toStringTrivia(t.Trailing))
+	return sb.String()
+}
+
+// Text returns the text of an atom, or the text of the first child of
+// a list, such as "and" or ":action".
+func (n *Node) Text() string {
+	if n.Kind == Atom {
+		return n.Token.Text
+	}
+	if len(n.Children) > 0 && n.Children[0].Kind == Atom {
+		return n.Children[0].Token.Text
+	}
+	return ""
+}
+
+// SetText replaces the text of an atom, keeping its trivia.
+func (n *Node) SetText(text string) error {
+	if n.Kind != Atom {
+		return fmt.Errorf("Failed to set text of a list")
+	}
+	tk := *n.Token
+	tk.Text = text
+	n.Token = &tk
+	return nil
+}
+
+// layout returns the trivia after the last comment, which lays out
+// the node, or a space.
+func layout(ts []*Trivia) []*Trivia {
+	for i := len(ts) - 1; i >= 0; i-- {
+		if ts[i].Kind == Comment {
+			ts = ts[i+1:]
+			break
+		}
+	}
+	if len(ts) == 0 {
+		return []*Trivia{{Kind: Whitespace, Text: " "}}
+	}
+	return append([]*Trivia{}, ts...)
+}
+
+// Insert adds a child to a list at index i. A child without leading
+// trivia is laid out as its neighbour; comments aren't copied, the
+// child takes the place of the next one, comments included.
+func (n *Node) Insert(i int, c *Node) error {
+	if n.Kind != List || i < 0 || i > len(n.Children) {
+		return fmt.Errorf("Failed to insert node at %d", i)
+	}
+	if len(c.Leading) == 0 {
+		// The head of a list has no leading trivia, don't copy it.
+		switch {
+		case i > 0 && i < len(n.Children):
+			next := n.Children[i]
+			c.Leading, next.Leading = next.Leading, layout(next.Leading)
+		case i > 1:
+			c.Leading = layout(n.Children[i-1].Leading)
+		default:
+			c.Leading = []*Trivia{{Kind: Whitespace, Text: " "}}
+		}
+	}
+	c.Parent = n
+	n.Children = append(n.Children, nil)
+	copy(n.Children[i+1:], n.Children[i:])
+	n.Children[i] = c
+	return nil
+}
+
+// Remove takes the child at index i out of a list, with its leading
+// trivia.
+func (n *Node) Remove(i int) (*Node, error) {
+	if n.Kind != List || i < 0 || i >= len(n.Children) {
+		return nil, fmt.Errorf("Failed to remove node at %d", i)
+	}
+	c := n.Children[i]
+	n.Children = append(n.Children[:i], n.Children[i+1:]...)
+	c.Parent = nil
+	return c, nil
+}
+
+// At returns the first node, in reading order, that starts at the
+// offset or after it, or nil. Lists come before their children.
+func (t *Tree) At(offset int) *Node {
+	var found *Node
+	var visit func(ns []*Node) bool
+	visit = func(ns []*Node) bool {
+		for _, n := range ns {
+			if n.Start >= offset {
+				found = n
+				return true
+			}
+			if n.End > offset && visit(n.Children) {
+				return true
+			}
+		}
+		return false
+	}
+	visit(t.Nodes)
+	return found
+}
+
+// Locate maps a location of the model to the node it was parsed from.
+func (t *Tree) Locate(loc *models.Location) *Node {
+	if loc == nil || loc.Path != t.Name {
+		return nil
+	}
+	return t.At(loc.Offset)
+}
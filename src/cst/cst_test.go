@@ -0,0 +1,130 @@
+package cst
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDomain = `; Blocks world, with comments kept.
+(define (domain blocks) ; the name
+  (:requirements :strips)
+	(:predicates (clear ?x)   (handempty))
+
+  (:action pick-up
+    :parameters (?x)
+    :precondition (and (clear ?x) (handempty)) ; both
+    :effect (not (clear ?x)))
+)
+; trailing comment`
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		testDomain,
+		"  \n; only a comment\n",
+		"(a)",
+		"(a (b c)\t(d))\n",
+		"(a)\r\n(b)\r\n",
+		"(define (problem p) (:init (= (f) -1.5e3) (at 10 (p))))\n",
+		"(é ;ü\n)",
+	}
+	for _, text := range tests {
+		tree, err := Read("test", text)
+		if err != nil {
+			t.Errorf("%q: %v", text, err)
+			continue
+		}
+		if got := tree.ToString(); got != text {
+			t.Errorf("got %q, want %q", got, text)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"(a (b)\n", "test:1: Failed to read: missing ) for the list opened here"},
+		{"(a))", "test:1: Failed to read: unbalanced )"},
+		{"(a\n  #)", "test:2: Failed to read"},
+	}
+	for _, test := range tests {
+		_, err := Read("test", test.text)
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("%q: got error %v, want %q", test.text, err, test.want)
+		}
+	}
+}
+
+func TestNodes(t *testing.T) {
+	tree, err := Read("test", testDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Nodes) != 1 || len(tree.Nodes[0].Children) != 5 {
+		t.Fatalf("got %d nodes", len(tree.Nodes))
+	}
+	action := tree.Nodes[0].Children[4]
+	if action.Text() != ":action" || action.Line != 6 || action.Parent != tree.Nodes[0] {
+		t.Errorf("got node %q at line %d", action.Text(), action.Line)
+	}
+	if got := testDomain[action.Start:action.End]; !strings.HasPrefix(got, "(:action pick-up") || !strings.HasSuffix(got, "(clear ?x)))") {
+		t.Errorf("got span %q", got)
+	}
+	if n := tree.At(action.Start + 1); n != action.Children[0] {
+		t.Errorf("got node %q after the start of the action", n.ToString())
+	}
+	tks := tree.Tokens()
+	if tks[0].Text != "(" || tks[len(tks)-1] != tree.EOF {
+		t.Errorf("got tokens from %q to %q", tks[0].Text, tks[len(tks)-1].Text)
+	}
+}
+
+func TestEdit(t *testing.T) {
+	tree, err := Read("test", "(and (p) ; first\n     (q))\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	and := tree.Nodes[0]
+	if err := and.Children[0].SetText("or"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := ReadNode("(r ?x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := and.Insert(2, n); err != nil {
+		t.Fatal(err)
+	}
+	want := "(or (p) ; first\n     (r ?x)\n     (q))\n"
+	if got := tree.ToString(); got != want {
+		t.Errorf("got %q after insert, want %q", got, want)
+	}
+	if _, err := and.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	want = "(or ; first\n     (r ?x)\n     (q))\n"
+	if got := tree.ToString(); got != want {
+		t.Errorf("got %q after remove, want %q", got, want)
+	}
+	last, err := ReadNode("(s)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := and.Insert(len(and.Children), last); err != nil {
+		t.Fatal(err)
+	}
+	want = "(or ; first\n     (r ?x)\n     (q)\n     (s))\n"
+	if got := tree.ToString(); got != want {
+		t.Errorf("got %q after append, want %q", got, want)
+	}
+	if n.Start != -1 || n.Parent != and {
+		t.Errorf("inserted node has span %d and parent %v", n.Start, n.Parent)
+	}
+	if err := and.SetText("x"); err == nil {
+		t.Errorf("setting the text of a list: got no error")
+	}
+	if _, err := ReadNode("(a) (b)"); err == nil {
+		t.Errorf("reading two nodes as one: got no error")
+	}
+}
@@ -0,0 +1,181 @@
+package cst
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/lexer"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// Stream reads the nodes of a text one at a time, without keeping
+// them, so that a text read by a lexer over a reader is never held in
+// memory as a whole. Lists can be entered, to read their children one
+// at a time as well. Streamed nodes have no trivia.
+type Stream struct {
+	lx *lexer.Lexer
+	// entered are the lists being read, innermost last.
+	entered []*Node
+	peeked  *lexer.ScannedToken
+}
+
+func NewStream(lx *lexer.Lexer) *Stream {
+	return &Stream{
+		lx: lx,
+	}
+}
+
+func (s *Stream) newError(line int, format string, args ...interface{}) *models.PddlError {
+	return &models.PddlError{
+		Location: &models.Location{
+			Path: s.lx.Name,
+			Line: line,
+		},
+		Error: fmt.Errorf(format, args...),
+	}
+}
+
+func (s *Stream) scan() (*lexer.ScannedToken, *models.PddlError) {
+	if tk := s.peeked; tk != nil {
+		s.peeked = nil
+		return tk, nil
+	}
+	tk, err := s.lx.ScanToken()
+	if err != nil {
+		return nil, s.newError(s.lx.CurrentLocator.LineNumber, "Failed to read: %v", err)
+	}
+	if tk.Type == lexer.TOKEN_ERROR {
+		return nil, s.newError(tk.Line, "Failed to read: %s", tk.Text)
+	}
+	return tk, nil
+}
+
+// Peek returns the next token without reading it.
+func (s *Stream) Peek() (*lexer.ScannedToken, *models.PddlError) {
+	tk, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+	s.peeked = tk
+	return tk, nil
+}
+
+// read reads the node that starts with the token.
+func (s *Stream) read(tk *lexer.ScannedToken) (*Node, *models.PddlError) {
+	if tk.Type != lexer.TOKEN_OPEN {
+		return &Node{
+			Kind:  Atom,
+			Token: tk,
+			Start: tk.Position,
+			End:   tk.Position + len(tk.Text),
+			Line:  tk.Line,
+		}, nil
+	}
+	n := &Node{
+		Kind:     List,
+		Token:    tk,
+		Children: []*Node{},
+		Start:    tk.Position,
+		Line:     tk.Line,
+	}
+	for {
+		c, err := s.scan()
+		if err != nil {
+			return nil, err
+		}
+		switch c.Type {
+		case lexer.TOKEN_EOF:
+			return nil, s.newError(n.Line, "Failed to read: missing ) for the list opened here")
+		case lexer.TOKEN_CLOSE:
+			n.Close = c
+			n.End = c.Position + 1
+			return n, nil
+		}
+		child, err := s.read(c)
+		if err != nil {
+			return nil, err
+		}
+		child.Parent = n
+		n.Children = append(n.Children, child)
+	}
+}
+
+// Next reads the next node of the list entered last, or of the text.
+// It returns nil at the end of the list, which is left, or at the end
+// of the text.
+func (s *Stream) Next() (*Node, *models.PddlError) {
+	tk, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+	switch tk.Type {
+	case lexer.TOKEN_EOF:
+		if len(s.entered) > 0 {
+			open := s.entered[len(s.entered)-1]
+			return nil, s.newError(open.Line, "Failed to read: missing ) for the list opened here")
+		}
+		s.peeked = tk
+		return nil, nil
+	case lexer.TOKEN_CLOSE:
+		if len(s.entered) == 0 {
+			return nil, s.newError(tk.Line, "Failed to read: unbalanced )")
+		}
+		n := s.entered[len(s.entered)-1]
+		n.Close = tk
+		n.End = tk.Position + 1
+		s.entered = s.entered[:len(s.entered)-1]
+		return nil, nil
+	}
+	return s.read(tk)
+}
+
+// Enter reads the opening of the next list, and its head when it is
+// an atom, such as ":init". The other children of the list are read
+// with Next.
+func (s *Stream) Enter() (*Node, *models.PddlError) {
+	tk, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+	if tk.Type != lexer.TOKEN_OPEN {
+		return nil, s.newError(tk.Line, "Failed to read: expected (, got %s", tk.Text)
+	}
+	n := &Node{
+		Kind:     List,
+		Token:    tk,
+		Children: []*Node{},
+		Start:    tk.Position,
+		Line:     tk.Line,
+	}
+	if len(s.entered) > 0 {
+		n.Parent = s.entered[len(s.entered)-1]
+	}
+	s.entered = append(s.entered, n)
+	head, err := s.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if head.Type != lexer.TOKEN_OPEN && head.Type != lexer.TOKEN_CLOSE && head.Type != lexer.TOKEN_EOF {
+		s.peeked = nil
+		h, _ := s.read(head)
+		h.Parent = n
+		n.Children = append(n.Children, h)
+	}
+	return n, nil
+}
+
+// NewTree returns the tree of a single node, such as a streamed one.
+func NewTree(name string, n *Node) *Tree {
+	end, line := n.End, n.Line
+	if n.Close != nil {
+		line = n.Close.Line
+	}
+	return &Tree{
+		Name:  name,
+		Nodes: []*Node{n},
+		EOF: &lexer.ScannedToken{
+			Type:     lexer.TOKEN_EOF,
+			Position: end,
+			Line:     line,
+		},
+	}
+}
@@ -2,6 +2,9 @@ package lexer
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -12,6 +15,10 @@ type Token int
 type ScannedToken struct {
 	Type Token
 	Text string
+
+	// Position is the offset of the token in the text, Line its line.
+	Position int
+	Line     int
 }
 
 type LexerLocator struct {
@@ -25,8 +32,19 @@ type Lexer struct {
 	Start          int
 	CurrentLocator *LexerLocator
 	Width          int
+
+	// A lexer over a reader has no Text: it buffers the text from the
+	// offset base, and drops it once it is scanned.
+	reader io.Reader
+	buf    []byte
+	base   int
+	done   bool
 }
 
+// readSize is the number of bytes a lexer reads at once from its
+// reader.
+const readSize = 64 * 1024
+
 const (
 	TOKEN_EOF   Token = Token(EOF)
 	TOKEN_OPEN  Token = '('
@@ -44,27 +62,27 @@ const (
 )
 
 var (
-	TokenNames = map[Token]string{}
-	RuneTokens = map[rune]Token{}
+	// TokenNames and RuneTokens are never modified, so that lexers can
+	// run concurrently.
+	TokenNames = map[Token]string{
+		TOKEN_ERROR:         "error",
+		TOKEN_OPEN:          "'('",
+		TOKEN_CLOSE:         "')'",
+		TOKEN_MINUS:         "'-'",
+		TOKEN_EQUAL:         "'='",
+		TOKEN_NAME:          "name",
+		TOKEN_CATEGORY_NAME: ":name",
+		TOKEN_VARIABLE_NAME: "?name",
+		TOKEN_NUMBER:        "number",
+	}
+	RuneTokens = map[rune]Token{
+		'(': TOKEN_OPEN,
+		')': TOKEN_CLOSE,
+		'-': TOKEN_MINUS,
+		'=': TOKEN_EQUAL,
+	}
 )
 
-func InitializeTokens() {
-	TokenNames[TOKEN_ERROR] = "error"
-	TokenNames[TOKEN_OPEN] = "'('"
-	TokenNames[TOKEN_CLOSE] = "')'"
-	TokenNames[TOKEN_MINUS] = "'-'"
-	TokenNames[TOKEN_EQUAL] = "'='"
-	TokenNames[TOKEN_NAME] = "name"
-	TokenNames[TOKEN_CATEGORY_NAME] = ":name"
-	TokenNames[TOKEN_VARIABLE_NAME] = "?name"
-	TokenNames[TOKEN_NUMBER] = "number"
-
-	RuneTokens['('] = TOKEN_OPEN
-	RuneTokens[')'] = TOKEN_CLOSE
-	RuneTokens['-'] = TOKEN_MINUS
-	RuneTokens['='] = TOKEN_EQUAL
-}
-
 func (t Token) ToString() (string, error) {
 	return TokenNames[t], nil
 }
@@ -73,7 +91,6 @@ func NewLexer(name string, text string) (*Lexer, error) {
 	if name == "" || text == "" {
 		return nil, fmt.Errorf("Failed to build new lexer: name and text not specified")
 	}
-	InitializeTokens()
 	return &Lexer{
 		Name: name,
 		Text: text,
@@ -83,6 +100,68 @@ func NewLexer(name string, text string) (*Lexer, error) {
 	}, nil
 }
 
+// NewReaderLexer returns a lexer that reads its text from r as it
+// scans it, so that its memory doesn't grow with the text.
+func NewReaderLexer(name string, r io.Reader) (*Lexer, error) {
+	if name == "" || r == nil {
+		return nil, fmt.Errorf("Failed to build new lexer: name and reader not specified")
+	}
+	return &Lexer{
+		Name:   name,
+		reader: r,
+		CurrentLocator: &LexerLocator{
+			LineNumber: 1,
+		},
+	}, nil
+}
+
+// Streamed tells whether the lexer reads its text from a reader, in
+// which case the text isn't available.
+func (l *Lexer) Streamed() bool {
+	return l.reader != nil
+}
+
+// fill reads from the reader until n bytes are buffered from the
+// position, or the reader is exhausted.
+func (l *Lexer) fill(n int) error {
+	for l.reader != nil && !l.done && len(l.buf)-(l.CurrentLocator.Position-l.base) < n {
+		if cap(l.buf)-len(l.buf) < readSize {
+			buf := make([]byte, len(l.buf), 2*cap(l.buf)+readSize)
+			copy(buf, l.buf)
+			l.buf = buf
+		}
+		m, err := l.reader.Read(l.buf[len(l.buf) : len(l.buf)+readSize])
+		l.buf = l.buf[:len(l.buf)+m]
+		if err == io.EOF {
+			l.done = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read %s: %v", l.Name, err)
+		}
+	}
+	return nil
+}
+
+// slice returns the text between two offsets, which must be buffered
+// for a lexer over a reader.
+func (l *Lexer) slice(start int, end int) string {
+	if l.reader == nil {
+		return l.Text[start:end]
+	}
+	return string(l.buf[start-l.base : end-l.base])
+}
+
+// drop forgets the buffered text before the start of the next token.
+func (l *Lexer) drop() {
+	n := l.Start - l.base
+	if l.reader == nil || n < readSize {
+		return
+	}
+	l.buf = l.buf[:copy(l.buf, l.buf[n:])]
+	l.base = l.Start
+}
+
 func (l *Lexer) Next() (rune, error) {
 	if l == nil {
 		return rune(0), fmt.Errorf("Failed to get next rune: lexer is nil")
@@ -90,11 +169,25 @@ func (l *Lexer) Next() (rune, error) {
 	if l.CurrentLocator == nil {
 		return rune(0), fmt.Errorf("Failed to get next rune: lexer locator is nil")
 	}
-	if l.CurrentLocator.Position >= len(l.Text) {
-		l.Width = 0
-		return EOF, nil
+	var r rune
+	var width int
+	if l.reader != nil {
+		err := l.fill(utf8.UTFMax)
+		if err != nil {
+			return rune(0), fmt.Errorf("Failed to get next rune: %v", err)
+		}
+		if l.CurrentLocator.Position-l.base >= len(l.buf) {
+			l.Width = 0
+			return EOF, nil
+		}
+		r, width = utf8.DecodeRune(l.buf[l.CurrentLocator.Position-l.base:])
+	} else {
+		if l.CurrentLocator.Position >= len(l.Text) {
+			l.Width = 0
+			return EOF, nil
+		}
+		r, width = utf8.DecodeRuneInString(l.Text[l.CurrentLocator.Position:])
 	}
-	r, width := utf8.DecodeRuneInString(l.Text[l.CurrentLocator.Position:])
 	l.Width = width
 	l.CurrentLocator.Position += width
 	if r == RETURN {
@@ -112,7 +205,7 @@ func (l *Lexer) Backup() error {
 	}
 	backedupRuneStart := l.CurrentLocator.Position - l.Width
 	backedupRuneEnd := l.CurrentLocator.Position
-	if strings.HasPrefix(l.Text[backedupRuneStart:backedupRuneEnd], "\n") {
+	if strings.HasPrefix(l.slice(backedupRuneStart, backedupRuneEnd), "\n") {
 		// If our location prefix is a return line, we go back one line
 		l.CurrentLocator.LineNumber -= 1
 	}
@@ -146,6 +239,7 @@ func (l *Lexer) Clear() error {
 		return fmt.Errorf("Can't clear lexer: lexer locator is nil")
 	}
 	l.Start = l.CurrentLocator.Position
+	l.drop()
 	return nil
 }
 
@@ -197,10 +291,13 @@ func (l *Lexer) CreateToken(t Token) (*ScannedToken, error) {
 		return nil, fmt.Errorf("Failed to create token from lexer: lexer locator is nil")
 	}
 	tk := &ScannedToken{
-		Type: t,
-		Text: l.Text[l.Start:l.CurrentLocator.Position],
+		Type:     t,
+		Text:     l.slice(l.Start, l.CurrentLocator.Position),
+		Position: l.Start,
+		Line:     l.CurrentLocator.LineNumber,
 	}
 	l.Start = l.CurrentLocator.Position
+	l.drop()
 	return tk, nil
 }
 
@@ -209,8 +306,10 @@ func (l *Lexer) TokenError(format string, args ...interface{}) (*ScannedToken, e
 		return nil, fmt.Errorf("Can't generate token error from lexer: lexer is nil")
 	}
 	return &ScannedToken{
-		Type: TOKEN_ERROR,
-		Text: fmt.Sprintf(format, args...),
+		Type:     TOKEN_ERROR,
+		Text:     fmt.Sprintf(format, args...),
+		Position: l.Start,
+		Line:     l.CurrentLocator.LineNumber,
 	}, nil
 }
 
@@ -239,23 +338,68 @@ func (l *Lexer) GetNameToken(t Token) (*ScannedToken, error) {
 	return tk, nil
 }
 
+// GetNumberToken scans a number from the start of the token: an
+// optional minus sign, digits, an optional fraction and an optional
+// exponent, such as -1.5e-3. A malformed number, or one too large for a
+// float64, is an error token.
 func (l *Lexer) GetNumberToken() (*ScannedToken, error) {
 	if l == nil {
 		return nil, fmt.Errorf("Can't get number from lexer: lexer is nil")
 	}
-	l.AcceptsSequence("-")
+	// The first rune was read to recognize the number.
+	l.CurrentLocator.Position = l.Start
 	digits := "0123456789"
-	l.AcceptsSequence(digits)
-	l.Accepts(".")
-	l.AcceptsSequence(digits)
-
-	ok, err := l.Accepts("eE")
+	_, err := l.Accepts("-")
+	if err != nil {
+		return nil, fmt.Errorf("Can't get number from lexer: %v", err)
+	}
+	ok, err := l.AcceptsSequence(digits)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get number from lexer: %v", err)
+	}
+	if !ok {
+		return l.malformed("number", "expected a digit")
+	}
+	ok, err = l.Accepts(".")
+	if err != nil {
+		return nil, fmt.Errorf("Can't get number from lexer: %v", err)
+	}
+	if ok {
+		ok, err = l.AcceptsSequence(digits)
+		if err != nil {
+			return nil, fmt.Errorf("Can't get number from lexer: %v", err)
+		}
+		if !ok {
+			return l.malformed("number", "expected a digit after the decimal point")
+		}
+	}
+	ok, err = l.Accepts("eE")
 	if err != nil {
 		return nil, fmt.Errorf("Can't get number from lexer: %v", err)
 	}
 	if ok {
-		l.Accepts("-")
-		l.AcceptsSequence(digits)
+		_, err = l.Accepts("+-")
+		if err != nil {
+			return nil, fmt.Errorf("Can't get number from lexer: %v", err)
+		}
+		ok, err = l.AcceptsSequence(digits)
+		if err != nil {
+			return nil, fmt.Errorf("Can't get number from lexer: %v", err)
+		}
+		if !ok {
+			return l.malformed("number", "expected a digit in the exponent")
+		}
+	}
+	r, err := l.Peek()
+	if err != nil {
+		return nil, fmt.Errorf("Can't get number from lexer: %v", err)
+	}
+	if isWordRune(r) {
+		return l.malformed("number", fmt.Sprintf("unexpected %q", r))
+	}
+	f, _ := strconv.ParseFloat(l.slice(l.Start, l.CurrentLocator.Position), 64)
+	if math.IsInf(f, 0) {
+		return l.malformed("number", "too large")
 	}
 	tk, err := l.CreateToken(TOKEN_NUMBER)
 	if err != nil {
@@ -264,11 +408,41 @@ func (l *Lexer) GetNumberToken() (*ScannedToken, error) {
 	return tk, nil
 }
 
+// isWordRune tells whether the rune can't directly follow a token
+// without a separator.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-+.", r)
+}
+
+// malformed returns an error token for the malformed word at the start
+// of the token, which is skipped.
+func (l *Lexer) malformed(what string, reason string) (*ScannedToken, error) {
+	for {
+		r, err := l.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Can't get %s from lexer: %v", what, err)
+		}
+		if r == EOF || !isWordRune(r) {
+			break
+		}
+	}
+	err := l.Backup()
+	if err != nil {
+		return nil, fmt.Errorf("Can't get %s from lexer: %v", what, err)
+	}
+	tk, err := l.TokenError("Malformed %s %s: %s", what, l.slice(l.Start, l.CurrentLocator.Position), reason)
+	if err != nil {
+		return nil, fmt.Errorf("Can't get %s from lexer: %v", what, err)
+	}
+	l.Start = l.CurrentLocator.Position
+	return tk, nil
+}
+
 func (l *Lexer) GetCommentToken() error {
 	if l == nil {
 		return fmt.Errorf("Can't get comment from lexer: lexer is nil")
 	}
-	for t, err := l.Next(); t != '\n'; t, err = l.Next() {
+	for t, err := l.Next(); t != '\n' && t != EOF; t, err = l.Next() {
 		if err != nil {
 			return fmt.Errorf("Can't get comment from lexer: %v", err)
 		}
@@ -293,7 +467,10 @@ func (l *Lexer) ScanToken() (*ScannedToken, error) {
 		if err != nil {
 			return nil, fmt.Errorf("Failed to scan token: %v", err)
 		}
-		if r == '-' && (unicode.IsDigit(rp) || rp == '-') {
+		if r == '-' && rp == '-' {
+			return l.malformed("token", "a minus sign can't be followed by another one")
+		}
+		if r == '-' && unicode.IsDigit(rp) {
 			tk, err := l.GetNumberToken()
 			if err != nil {
 				return nil, fmt.Errorf("Failed to scan token: %v", err)
@@ -348,8 +525,72 @@ func (l *Lexer) ScanToken() (*ScannedToken, error) {
 				return nil, fmt.Errorf("Failed to scan token: %v", err)
 			}
 			return tk, err
+		case r == '<' || r == '>':
+			_, err = l.Accepts("=")
+			if err != nil {
+				return nil, fmt.Errorf("Failed to scan token: %v", err)
+			}
+			tk, err := l.CreateToken(TOKEN_NAME)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to scan token: %v", err)
+			}
+			return tk, nil
+		case r == '#':
+			// PDDL+ continuous time
+			tk, err := l.GetNameToken(TOKEN_NAME)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to scan token: %v", err)
+			}
+			if tk.Text != "#t" {
+				etk, err := l.TokenError("Unhandled token in PDDL: %s", tk.Text)
+				if err != nil {
+					return nil, fmt.Errorf("Failed to scan token: %v", err)
+				}
+				etk.Position = tk.Position
+				return etk, nil
+			}
+			return tk, nil
+		case r == '+' || r == '*' || r == '/':
+			tk, err := l.CreateToken(TOKEN_NAME)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to scan token: %v", err)
+			}
+			return tk, nil
 		default:
 			return l.TokenError("Unhandled token in PDDL: %c", r)
 		}
 	}
 }
+
+// Quote returns the line of the text where the token is, with a caret
+// under the token, or nothing for a lexer over a reader.
+func (l *Lexer) Quote(tk *ScannedToken) string {
+	text := l.Text
+	if l.Streamed() || tk.Position > len(text) {
+		return ""
+	}
+	start := strings.LastIndex(text[:tk.Position], "\n") + 1
+	end := strings.Index(text[tk.Position:], "\n")
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += tk.Position
+	}
+	margin := fmt.Sprintf("%5d | ", tk.Line)
+	// Keep the tabs so that the caret lines up.
+	var indent strings.Builder
+	for _, r := range text[start:tk.Position] {
+		if r == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+	width := utf8.RuneCountInString(tk.Text)
+	if width == 0 || tk.Type == TOKEN_ERROR {
+		width = 1
+	}
+	s := margin + text[start:end] + "\n"
+	s += strings.Repeat(" ", len(margin)-2) + "| " + indent.String() + strings.Repeat("^", width)
+	return s
+}
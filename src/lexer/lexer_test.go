@@ -0,0 +1,105 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+// scan returns the tokens of the text, up to the first error token
+// included, without the EOF token.
+func scan(t *testing.T, text string) []*ScannedToken {
+	lx, err := NewLexer("test", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tks := []*ScannedToken{}
+	for {
+		tk, err := lx.ScanToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tk.Type == TOKEN_EOF {
+			return tks
+		}
+		tks = append(tks, tk)
+		if tk.Type == TOKEN_ERROR {
+			return tks
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	for _, text := range []string{"0", "12", "-7", "0.5", "-0.25", "1.5e3", "1E-3", "2e+5", "-1.5e-3"} {
+		tks := scan(t, text)
+		if len(tks) != 1 || tks[0].Type != TOKEN_NUMBER || tks[0].Text != text {
+			t.Errorf("%q: got %d tokens, the first %+v", text, len(tks), *tks[0])
+		}
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"1.", "Malformed number 1.: expected a digit after the decimal point"},
+		{"--5", "Malformed token --5: a minus sign can't be followed by another one"},
+		{"1e", "Malformed number 1e: expected a digit in the exponent"},
+		{"1e+", "Malformed number 1e+: expected a digit in the exponent"},
+		{"5a", "Malformed number 5a: unexpected 'a'"},
+		{"1.2.3", "Malformed number 1.2.3: unexpected '.'"},
+		{"-3-", "Malformed number -3-: unexpected '-'"},
+		{"1e999", "Malformed number 1e999: too large"},
+	}
+	for _, test := range tests {
+		tks := scan(t, "(f "+test.text+" 2)")
+		last := tks[len(tks)-1]
+		if last.Type != TOKEN_ERROR || last.Text != test.want {
+			t.Errorf("%q: got %+v, want the error %q", test.text, *last, test.want)
+		}
+	}
+}
+
+func TestMinus(t *testing.T) {
+	tks := scan(t, "(- (f) -1)\n(- 3 2)")
+	types := []string{}
+	for _, tk := range tks {
+		s, _ := tk.Type.ToString()
+		types = append(types, s)
+	}
+	want := "'(' '-' '(' name ')' number ')' '(' '-' number number ')'"
+	if got := strings.Join(types, " "); got != want {
+		t.Errorf("got tokens %s, want %s", got, want)
+	}
+	if tks[5].Text != "-1" || tks[8].Line != 2 {
+		t.Errorf("got %q, line %d", tks[5].Text, tks[8].Line)
+	}
+}
+
+func TestMalformedSkipsWord(t *testing.T) {
+	lx, err := NewLexer("test", "(f 1.x 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := []string{}
+	for tk, err := lx.ScanToken(); err == nil && tk.Type != TOKEN_EOF; tk, err = lx.ScanToken() {
+		texts = append(texts, tk.Text)
+	}
+	want := "( f Malformed number 1.x: expected a digit after the decimal point 2 )"
+	if got := strings.Join(texts, " "); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnhandledToken(t *testing.T) {
+	for _, text := range []string{"(a #)", "(a #x)", "(a .5)"} {
+		tks := scan(t, text)
+		last := tks[len(tks)-1]
+		if last.Type != TOKEN_ERROR || last.Position != 3 {
+			t.Errorf("%q: got %+v, want an error at 3", text, *last)
+		}
+	}
+	if tks := scan(t, "(increase #t 1)"); tks[2].Type != TOKEN_NAME || tks[2].Text != "#t" {
+		t.Errorf("got %+v, want #t", *tks[2])
+	}
+}
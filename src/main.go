@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 
+	"github.com/guilyx/go-pddl/src/models"
 	"github.com/guilyx/go-pddl/src/services"
+	"github.com/guilyx/go-pddl/src/validator"
 )
 
 func main() {
@@ -26,10 +28,47 @@ func main() {
 		panic("Failed to parse problem")
 	}
 	fmt.Println("Problem successfully parsed...")
+	skipped := append(pddl.Parser.DomainToolbox.Skipped, pddl.Parser.ProblemToolbox.Skipped...)
+	for _, s := range skipped {
+		loc, _ := s.Location.ToString()
+		fmt.Printf("%s: skipped unsupported section %s\n", loc, s.Name)
+	}
+	types, errPddl := models.NewTypeHierarchy(d, pb)
+	if errPddl != nil {
+		fmt.Println(errPddl.ToError())
+		panic("Failed to type problem objects")
+	}
 
-	d.ToStringDomain()
+	d.PrintDomain()
 	fmt.Printf("\n\n")
-	pb.ToStringProblem()
+	pb.PrintProblem()
+
+	conf := pddl.Parser.ProblemToolbox.Configuration
+
+	// Analyze
+	if conf.Report {
+		fmt.Printf("\n\n")
+		fmt.Print(report(types, d))
+	}
+
+	// Validate
+	if conf.Plan != "" {
+		plan, errPddl := pddl.Parser.ParsePlan(conf.Plan, d, pb)
+		if errPddl != nil {
+			fmt.Println(errPddl.ToError())
+			panic("Failed to parse plan")
+		}
+		v, err := validator.NewValidator(d, pb)
+		if err != nil {
+			panic(err)
+		}
+		report, err := v.Validate(plan)
+		if err != nil {
+			fmt.Println(err)
+			panic("Failed to validate plan")
+		}
+		fmt.Println(report.ToString())
+	}
 
 	// Plan
 	// err = pddl.RegisterPlanner(d, pb)
@@ -37,3 +76,9 @@ func main() {
 	// 	panic("Exit failure")
 	// }
 }
+
+// report describes the type hierarchy and the mutability of the
+// predicates and functions.
+func report(types *models.TypeHierarchy, d *models.Domain) string {
+	return types.ToString() + d.Analyze().ToString()
+}
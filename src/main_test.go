@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+	"github.com/guilyx/go-pddl/src/parser"
+)
+
+func TestReport(t *testing.T) {
+	domain := `(define (domain lift)
+  (:requirements :strips :typing :numeric-fluents)
+  (:types floor person)
+  (:predicates (at ?p - person ?f - floor) (above ?f ?g - floor))
+  (:functions (moves))
+  (:action move
+    :parameters (?p - person ?f ?g - floor)
+    :precondition (and (at ?p ?f) (above ?g ?f))
+    :effect (and (not (at ?p ?f)) (at ?p ?g) (increase (moves) 1))))
+`
+	problem := `(define (problem lift) (:domain lift)
+  (:objects f1 f2 - floor ann - person)
+  (:init (at ann f1) (above f2 f1) (= (moves) 0))
+  (:goal (at ann f2)))
+`
+	d, pb, err := parser.ParseTexts(&config.Config{}, domain, problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, perr := models.NewTypeHierarchy(d, pb)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	i := models.Indent(1)
+	want := "object\n" +
+		i + "floor: f1 f2\n" +
+		i + "person: ann\n" +
+		"Predicates:\n" +
+		i + "above  static\n" +
+		i + "at     fluent\n" +
+		"Functions:\n" +
+		i + "moves  monotone increasing\n"
+	if got := report(types, d); got != want {
+		t.Errorf("got report\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// Factor is the domain and problem of one agent of a factored MA-PDDL
+// task, as read from the agent's own files.
+type Factor struct {
+	Agent   string
+	Domain  *Domain
+	Problem *Problem
+}
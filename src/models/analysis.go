@@ -0,0 +1,173 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mutability tells how the actions may change a predicate or a
+// numeric fluent.
+type Mutability int
+
+const (
+	// Static symbols are never changed: their value is the initial one.
+	Static Mutability = iota
+	// Increasing predicates are only added, increasing functions
+	// only increased.
+	Increasing
+	// Decreasing predicates are only deleted, decreasing functions
+	// only decreased.
+	Decreasing
+	// Fluent symbols are changed in both directions, or assigned.
+	Fluent
+)
+
+var (
+	MutabilityNames = map[Mutability]string{
+		Static:     "static",
+		Increasing: "monotone increasing",
+		Decreasing: "monotone decreasing",
+		Fluent:     "fluent",
+	}
+)
+
+func (m Mutability) ToString() string {
+	return MutabilityNames[m]
+}
+
+// IsMonotone tells whether the symbol only changes in one direction.
+func (m Mutability) IsMonotone() bool {
+	return m == Increasing || m == Decreasing
+}
+
+// Analysis is the mutability of every predicate and function of a
+// domain.
+type Analysis struct {
+	Predicates map[string]Mutability
+	Functions  map[string]Mutability
+}
+
+func mutability(pos bool, neg bool) Mutability {
+	switch {
+	case pos && neg:
+		return Fluent
+	case pos:
+		return Increasing
+	case neg:
+		return Decreasing
+	}
+	return Static
+}
+
+// Analyze scans the effects of every action, process and event,
+// including quantified and conditional ones, sets the PosEffect and
+// NegEffect flags of the predicates and returns the mutability of the
+// predicates and functions.
+func (d *Domain) Analyze() *Analysis {
+	preds := map[string]*Predicate{}
+	for _, p := range d.Predicates {
+		p.PosEffect, p.NegEffect = false, false
+		preds[p.Name.Name] = p
+	}
+	// increased and decreased functions, and the assigned ones
+	// in both.
+	incs, decs := map[string]bool{}, map[string]bool{}
+	var visit func(f Formula) bool
+	visit = func(f Formula) bool {
+		switch n := f.(type) {
+		case *WhenNode:
+			// The condition doesn't change the state.
+			Inspect(n.UnaryNode.Formula, visit)
+			return false
+		case *LiteralNode:
+			if p, ok := preds[n.Predicate.Name]; ok {
+				if n.Negative {
+					p.NegEffect = true
+				} else {
+					p.PosEffect = true
+				}
+			}
+		case *AssignNode:
+			name := n.AssignedTo.Name.Name
+			switch n.Operation.Name {
+			case "increase":
+				incs[name] = true
+			case "decrease":
+				decs[name] = true
+			default:
+				incs[name], decs[name] = true, true
+			}
+		}
+		return true
+	}
+	for _, a := range d.Structures() {
+		Inspect(a.Effect, visit)
+	}
+
+	an := &Analysis{
+		Predicates: map[string]Mutability{},
+		Functions:  map[string]Mutability{},
+	}
+	for _, p := range d.Predicates {
+		an.Predicates[p.Name.Name] = mutability(p.PosEffect, p.NegEffect)
+	}
+	for _, f := range d.Functions {
+		an.Functions[f.Name.Name] = mutability(incs[f.Name.Name], decs[f.Name.Name])
+	}
+	return an
+}
+
+// IsStatic tells whether the predicate or function is never changed,
+// so that its atoms can be evaluated once against the initial state.
+func (an *Analysis) IsStatic(name string) bool {
+	if m, ok := an.Predicates[name]; ok {
+		return m == Static
+	}
+	if m, ok := an.Functions[name]; ok {
+		return m == Static
+	}
+	return false
+}
+
+// StaticPredicates returns the sorted names of the static predicates.
+func (an *Analysis) StaticPredicates() []string {
+	names := []string{}
+	for n, m := range an.Predicates {
+		if m == Static {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mutabilityTable(title string, ms map[string]Mutability) string {
+	names := []string{}
+	width := 0
+	for n := range ms {
+		names = append(names, n)
+		if len(n) > width {
+			width = len(n)
+		}
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString(title + ":\n")
+	for _, n := range names {
+		sb.WriteString(fmt.Sprintf("%s%-*s  %s\n", Indent(1), width, n, ms[n].ToString()))
+	}
+	return sb.String()
+}
+
+func (an *Analysis) ToString() string {
+	s := mutabilityTable("Predicates", an.Predicates)
+	if len(an.Functions) > 0 {
+		s += mutabilityTable("Functions", an.Functions)
+	}
+	return s
+}
+
+func (an *Analysis) PrintAnalysis() {
+	fmt.Print(an.ToString())
+}
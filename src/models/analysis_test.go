@@ -0,0 +1,87 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+	"github.com/guilyx/go-pddl/src/parser"
+)
+
+const analysisDomain = `(define (domain lab)
+  (:requirements :strips :negative-preconditions :conditional-effects :numeric-fluents :time)
+  (:predicates (linked ?x ?y) (lit ?x) (fresh ?x) (busy) (hot ?x) (cold ?x))
+  (:functions (size ?x) (energy) (wear) (level))
+  (:action heat
+    :parameters (?x)
+    :precondition (and (linked ?x ?x) (not (busy)))
+    :effect (and (busy) (forall (?y) (when (and (linked ?x ?y) (cold ?y)) (hot ?y)))))
+  (:action rest
+    :parameters ()
+    :precondition (busy)
+    :effect (and (not (busy)) (forall (?y) (not (fresh ?y))) (assign (level) 0)))
+  (:process burn
+    :parameters (?x)
+    :precondition (lit ?x)
+    :effect (and (decrease (energy) (size ?x)) (increase (wear) 1))))
+`
+
+const analysisProblem = `(define (problem lab) (:domain lab)
+  (:objects a b)
+  (:init (linked a b) (lit a) (= (size a) 1) (= (energy) 10) (= (wear) 0) (= (level) 0))
+  (:goal (hot b)))
+`
+
+func TestAnalyze(t *testing.T) {
+	d, _, err := parser.ParseTexts(&config.Config{}, analysisDomain, analysisProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	an := d.Analyze()
+	preds := map[string]models.Mutability{
+		"linked": models.Static,
+		"lit":    models.Static,
+		"cold":   models.Static,
+		"hot":    models.Increasing,
+		"fresh":  models.Decreasing,
+		"busy":   models.Fluent,
+	}
+	for name, want := range preds {
+		if got := an.Predicates[name]; got != want {
+			t.Errorf("predicate %s: got %s, want %s", name, got.ToString(), want.ToString())
+		}
+	}
+	funcs := map[string]models.Mutability{
+		"size":   models.Static,
+		"wear":   models.Increasing,
+		"energy": models.Decreasing,
+		"level":  models.Fluent,
+	}
+	for name, want := range funcs {
+		if got := an.Functions[name]; got != want {
+			t.Errorf("function %s: got %s, want %s", name, got.ToString(), want.ToString())
+		}
+	}
+	flags := []string{}
+	for _, p := range d.Predicates {
+		flags = append(flags, p.Name.Name+map[[2]bool]string{
+			{false, false}: "",
+			{true, false}:  "+",
+			{false, true}:  "-",
+			{true, true}:   "+-",
+		}[[2]bool{p.PosEffect, p.NegEffect}])
+	}
+	if got := strings.Join(flags, " "); got != "linked lit fresh- busy+- hot+ cold" {
+		t.Errorf("got effect flags %s", got)
+	}
+	if !an.IsStatic("size") || an.IsStatic("hot") || an.IsStatic("unknown") {
+		t.Errorf("got wrong IsStatic answers")
+	}
+	if got := strings.Join(an.StaticPredicates(), " "); got != "cold linked lit" {
+		t.Errorf("got static predicates %s", got)
+	}
+	if !models.Increasing.IsMonotone() || models.Fluent.IsMonotone() || models.Static.IsMonotone() {
+		t.Errorf("got wrong IsMonotone answers")
+	}
+}
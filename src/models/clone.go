@@ -0,0 +1,273 @@
+package models
+
+// Clone functions copy the tree structure of the model. Names and
+// locations are shared, they are never modified in place.
+
+func (d *Domain) Clone() *Domain {
+	c := *d
+	c.Requirements = append([]*Name{}, d.Requirements...)
+	c.Types = make([]*Type, len(d.Types))
+	for i, t := range d.Types {
+		ct := *t
+		ct.TypedEntry = t.TypedEntry.Clone()
+		c.Types[i] = &ct
+	}
+	c.Constants = CloneTypedEntries(d.Constants)
+	c.Predicates = make([]*Predicate, len(d.Predicates))
+	for i, p := range d.Predicates {
+		cp := *p
+		cp.Parameters = CloneTypedEntries(p.Parameters)
+		c.Predicates[i] = &cp
+	}
+	c.Functions = make([]*Function, len(d.Functions))
+	for i, f := range d.Functions {
+		cf := *f
+		cf.Params = CloneTypedEntries(f.Params)
+		c.Functions[i] = &cf
+	}
+	c.Actions = make([]*Action, len(d.Actions))
+	for i, a := range d.Actions {
+		c.Actions[i] = a.Clone()
+	}
+	c.Processes = cloneActions(d.Processes)
+	c.Events = cloneActions(d.Events)
+	if d.Tasks != nil {
+		c.Tasks = make([]*Task, len(d.Tasks))
+		for i, t := range d.Tasks {
+			ct := *t
+			ct.Params = CloneTypedEntries(t.Params)
+			c.Tasks[i] = &ct
+		}
+	}
+	if d.Methods != nil {
+		c.Methods = make([]*Method, len(d.Methods))
+		for i, m := range d.Methods {
+			c.Methods[i] = m.Clone()
+		}
+	}
+	return &c
+}
+
+func cloneActions(as []*Action) []*Action {
+	if as == nil {
+		return nil
+	}
+	c := make([]*Action, len(as))
+	for i, a := range as {
+		c[i] = a.Clone()
+	}
+	return c
+}
+
+func (tn *TaskNetwork) Clone() *TaskNetwork {
+	if tn == nil {
+		return nil
+	}
+	c := *tn
+	c.Params = CloneTypedEntries(tn.Params)
+	c.Subtasks = make([]*Subtask, len(tn.Subtasks))
+	for i, st := range tn.Subtasks {
+		cs := *st
+		cs.Terms = CloneTerms(st.Terms)
+		c.Subtasks[i] = &cs
+	}
+	c.Orderings = append([]*Ordering{}, tn.Orderings...)
+	return &c
+}
+
+func (m *Method) Clone() *Method {
+	c := *m
+	c.Params = CloneTypedEntries(m.Params)
+	c.TaskTerms = CloneTerms(m.TaskTerms)
+	c.Precondition = CloneFormula(m.Precondition)
+	c.Network = m.Network.Clone()
+	return &c
+}
+
+func (p *Problem) Clone() *Problem {
+	c := *p
+	c.Requirements = append([]*Name{}, p.Requirements...)
+	c.Objects = CloneTypedEntries(p.Objects)
+	c.Htn = p.Htn.Clone()
+	c.InitialConditions = make([]Formula, len(p.InitialConditions))
+	for i, f := range p.InitialConditions {
+		c.InitialConditions[i] = CloneFormula(f)
+	}
+	c.Goal = CloneFormula(p.Goal)
+	return &c
+}
+
+func (a *Action) Clone() *Action {
+	c := *a
+	c.Params = CloneTypedEntries(a.Params)
+	c.Precondition = CloneFormula(a.Precondition)
+	c.Effect = CloneFormula(a.Effect)
+	return &c
+}
+
+func (te *TypedEntry) Clone() *TypedEntry {
+	c := *te
+	c.Types = append([]*TypeName{}, te.Types...)
+	return &c
+}
+
+func CloneTypedEntries(tes []*TypedEntry) []*TypedEntry {
+	if tes == nil {
+		return nil
+	}
+	c := make([]*TypedEntry, len(tes))
+	for i, te := range tes {
+		c[i] = te.Clone()
+	}
+	return c
+}
+
+func CloneTerms(ts []*Term) []*Term {
+	if ts == nil {
+		return nil
+	}
+	c := make([]*Term, len(ts))
+	for i, t := range ts {
+		c[i] = t.Clone()
+	}
+	return c
+}
+
+func (t *Term) Clone() *Term {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.Function = t.Function.Clone()
+	return &c
+}
+
+func (h *FunctionInit) Clone() *FunctionInit {
+	if h == nil {
+		return nil
+	}
+	c := *h
+	c.Terms = CloneTerms(h.Terms)
+	return &c
+}
+
+func (e *NumericExpression) Clone() *NumericExpression {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	c.FunctionInit = e.FunctionInit.Clone()
+	if e.Operands != nil {
+		c.Operands = make([]*NumericExpression, len(e.Operands))
+		for i, o := range e.Operands {
+			c.Operands[i] = o.Clone()
+		}
+	}
+	return &c
+}
+
+func cloneUnary(u *UnaryNode) *UnaryNode {
+	return &UnaryNode{
+		Node:    u.Node,
+		Formula: CloneFormula(u.Formula),
+	}
+}
+
+func cloneQuant(q *QuantNode) *QuantNode {
+	return &QuantNode{
+		Variables: CloneTypedEntries(q.Variables),
+		UnaryNode: cloneUnary(q.UnaryNode),
+	}
+}
+
+func cloneMulti(m *MultiNode) *MultiNode {
+	c := &MultiNode{
+		Node:    m.Node,
+		Formula: make([]Formula, len(m.Formula)),
+	}
+	for i, f := range m.Formula {
+		c.Formula[i] = CloneFormula(f)
+	}
+	return c
+}
+
+// CloneFormula returns a deep copy of the formula.
+func CloneFormula(f Formula) Formula {
+	switch n := f.(type) {
+	case nil:
+		return nil
+	case *LiteralNode:
+		c := *n
+		c.Terms = CloneTerms(n.Terms)
+		return &c
+	case *AndNode:
+		return &AndNode{
+			MultiNode: cloneMulti(n.MultiNode),
+		}
+	case *OrNode:
+		return &OrNode{
+			MultiNode: cloneMulti(n.MultiNode),
+		}
+	case *OneOfNode:
+		return &OneOfNode{
+			MultiNode: cloneMulti(n.MultiNode),
+		}
+	case *NotNode:
+		return &NotNode{
+			UnaryNode: cloneUnary(n.UnaryNode),
+		}
+	case *ImplyNode:
+		return &ImplyNode{
+			BinaryNode: &BinaryNode{
+				Node:  n.BinaryNode.Node,
+				Left:  CloneFormula(n.BinaryNode.Left),
+				Right: CloneFormula(n.BinaryNode.Right),
+			},
+		}
+	case *ForAllNode:
+		return &ForAllNode{
+			QuantNode: cloneQuant(n.QuantNode),
+			IsEffect:  n.IsEffect,
+		}
+	case *ExistsNode:
+		return &ExistsNode{
+			QuantNode: cloneQuant(n.QuantNode),
+		}
+	case *WhenNode:
+		return &WhenNode{
+			Condition: CloneFormula(n.Condition),
+			UnaryNode: cloneUnary(n.UnaryNode),
+		}
+	case *ProbabilisticNode:
+		c := &ProbabilisticNode{
+			Node:     n.Node,
+			Outcomes: make([]*ProbabilisticOutcome, len(n.Outcomes)),
+		}
+		for i, o := range n.Outcomes {
+			c.Outcomes[i] = &ProbabilisticOutcome{
+				Probability: o.Probability,
+				Formula:     CloneFormula(o.Formula),
+			}
+		}
+		return c
+	case *TimedInitNode:
+		return &TimedInitNode{
+			Node:      n.Node,
+			Time:      n.Time,
+			UnaryNode: cloneUnary(n.UnaryNode),
+		}
+	case *AssignNode:
+		c := *n
+		c.AssignedTo = n.AssignedTo.Clone()
+		c.FunctionInit = n.FunctionInit.Clone()
+		c.Expression = n.Expression.Clone()
+		c.Term = n.Term.Clone()
+		return &c
+	case *CompareNode:
+		c := *n
+		c.Left = n.Left.Clone()
+		c.Right = n.Right.Clone()
+		return &c
+	}
+	panic("CloneFormula: unhandled node type")
+}
@@ -41,13 +41,25 @@ type (
 type Name struct {
 	Name     string
 	Location *Location
+
+	// Original is the spelling of the name in the file when it differs
+	// from Name, which is normalized because PDDL is case-insensitive.
+	Original string
 }
 
 func (n *Name) ToString() (string, error) {
 	if n == nil {
 		return "", fmt.Errorf("Failed to stringify, name is nil")
 	}
-	return n.Name, nil
+	return n.Text(), nil
+}
+
+// Text returns the name as it was spelled, for printing.
+func (n *Name) Text() string {
+	if n.Original != "" {
+		return n.Original
+	}
+	return n.Name
 }
 
 type TypeName struct {
@@ -59,6 +71,9 @@ type TypedEntry struct {
 	Name  *Name
 	Id    int
 	Types []*TypeName
+	// Private objects and constants are only known to the agent
+	// declaring them (MA-PDDL).
+	Private bool
 }
 
 type Type struct {
@@ -73,10 +88,13 @@ type FunctionInit struct {
 	Definition *Function
 }
 
+// Term is a constant, a variable or, with object fluents, a function
+// term such as (loc ?t), in which case Name is the function name.
 type Term struct {
 	Name       *Name
 	IsVariable bool
 	Definition *TypedEntry
+	Function   *FunctionInit
 }
 
 type Function struct {
@@ -86,6 +104,12 @@ type Function struct {
 	Params []*TypedEntry
 }
 
+// IsObject tells whether the function returns objects rather than
+// numbers (PDDL 3.1 object fluents).
+func (f *Function) IsObject() bool {
+	return len(f.Types) > 0 && f.Types[0].Name.Name != "number"
+}
+
 func Indent(n int) (s string) {
 	for i := 0; i < n; i++ {
 		s += "\t"
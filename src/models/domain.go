@@ -12,24 +12,54 @@ type Domain struct {
 	Predicates   []*Predicate
 	Functions    []*Function
 	Actions      []*Action
+	// PDDL+ processes and events, which share the structure of actions
+	Processes []*Action
+	Events    []*Action
+	// HDDL abstract tasks and the methods decomposing them
+	Tasks   []*Task
+	Methods []*Method
+}
+
+// Structures returns the actions, processes and events of the domain.
+func (d *Domain) Structures() []*Action {
+	s := append([]*Action{}, d.Actions...)
+	s = append(s, d.Processes...)
+	return append(s, d.Events...)
 }
 
 func (d *Domain) PrintDomain() {
-	var s string
 	if d == nil {
 		panic("Domain is nil, can't print")
 	}
-	s += fmt.Sprintf("(define (domain %s)\n", d.Name.Name)
+	fmt.Println(d.ToString())
+}
+
+// ToString returns the domain in PDDL syntax.
+func (d *Domain) ToString() string {
+	var s string
+	s += fmt.Sprintf("(define (domain %s)\n", d.Name.Text())
 	s += toStringReqs(d.Requirements)
 	s += toStringTypesDef(d.Types)
 	s += toStringConsts(":constants", d.Constants)
 	s += toStringPredicates(d.Predicates)
 	s += toStringFunctions(d.Functions)
+	for _, t := range d.Tasks {
+		s += toStringTask(t)
+	}
+	for _, m := range d.Methods {
+		s += toStringMethod(m)
+	}
 	for _, act := range d.Actions {
-		s += toStringAction(act)
+		s += toStringAction(":action", act)
+	}
+	for _, pr := range d.Processes {
+		s += toStringAction(":process", pr)
+	}
+	for _, ev := range d.Events {
+		s += toStringAction(":event", ev)
 	}
 	s += ")\n"
-	fmt.Println(s)
+	return s
 }
 
 func (d *Domain) ToJSONDomain() {
@@ -38,14 +68,26 @@ func (d *Domain) ToJSONDomain() {
 		panic("Domain is nil, can't convert to json")
 	}
 	s += "{"
-	s += fmt.Sprintf("\"define\":{\"domain\":\"%s\"},", d.Name.Name)
+	s += fmt.Sprintf("\"define\":{\"domain\":\"%s\"},", d.Name.Text())
 	s += toJSONReqs(d.Requirements)
 	s += toJSONTypesDef(d.Types)
 	s += toJSONConsts("constants", d.Constants)
 	s += toJSONPredicates(d.Predicates)
 	s += toJSONFunctions(d.Functions)
+	for _, t := range d.Tasks {
+		s += toJSONTask(t)
+	}
+	for _, m := range d.Methods {
+		s += toJSONMethod(m)
+	}
 	for _, act := range d.Actions {
-		s += toJSONAction(act)
+		s += toJSONAction("action", act)
+	}
+	for _, pr := range d.Processes {
+		s += toJSONAction("process", pr)
+	}
+	for _, ev := range d.Events {
+		s += toJSONAction("event", ev)
 	}
 	s += "}"
 	fmt.Println(s)
@@ -0,0 +1,567 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Evaluable evaluates a goal description under the bindings of its
+// free variables. Nodes that can't appear in a goal description,
+// such as when and assignments, return an error.
+type Evaluable interface {
+	Evaluate(env *Env, b Bindings) (bool, error)
+}
+
+// Effect records the ground changes of an effect into the delta
+// without touching the state. Nodes that can't appear in an effect,
+// such as or and exists, return an error.
+type Effect interface {
+	Collect(env *Env, b Bindings, d *Delta) error
+}
+
+// Universe maps every type name to its objects, supertypes included.
+type Universe map[string][]string
+
+// Env is what formulas are evaluated against.
+type Env struct {
+	State    *State
+	Universe Universe
+	// Time is the value of (total-time) when the task
+	// doesn't declare it as a fluent.
+	Time float64
+	// Step is the value of #t in the continuous effects of
+	// PDDL+ processes.
+	Step float64
+}
+
+// Bindings maps variable names to objects.
+type Bindings map[string]string
+
+// NewUniverse maps every type of the hierarchy of the domain to its
+// constants and the objects of the problem.
+func NewUniverse(d *Domain, pb *Problem) (Universe, error) {
+	h, err := NewTypeHierarchy(d, pb)
+	if err != nil {
+		return nil, err.ToError()
+	}
+	u := Universe{}
+	for t := range h.Types {
+		for _, e := range h.ObjectsOfType(t) {
+			u[t] = append(u[t], e.Name.Name)
+		}
+	}
+	return u, nil
+}
+
+// OfTypes returns the objects belonging to at least one of the
+// types; an empty type list stands for object.
+func (u Universe) OfTypes(ts []*TypeName) []string {
+	if len(ts) == 0 {
+		return u["object"]
+	}
+	if len(ts) == 1 {
+		return u[ts[0].Name.Name]
+	}
+	seen := map[string]bool{}
+	objs := []string{}
+	for _, t := range ts {
+		for _, o := range u[t.Name.Name] {
+			if !seen[o] {
+				seen[o] = true
+				objs = append(objs, o)
+			}
+		}
+	}
+	return objs
+}
+
+func (u Universe) HasType(obj string, ts []*TypeName) bool {
+	for _, o := range u.OfTypes(ts) {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
+
+func (b Bindings) With(name, value string) Bindings {
+	c := make(Bindings, len(b)+1)
+	for k, v := range b {
+		c[k] = v
+	}
+	c[name] = value
+	return c
+}
+
+// Ground replaces the variables among the terms by their values.
+func (b Bindings) Ground(terms []*Term) ([]string, error) {
+	args := make([]string, len(terms))
+	for i, t := range terms {
+		if t.Function != nil {
+			return nil, fmt.Errorf("function term %s needs a state", t.ToString())
+		}
+		if !strings.HasPrefix(t.Name.Name, "?") {
+			args[i] = t.Name.Name
+			continue
+		}
+		v, ok := b[t.Name.Name]
+		if !ok {
+			return nil, fmt.Errorf("unbound variable %s", t.Name.Name)
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// Ground replaces the variables among the terms by their values and
+// the function terms by the objects they currently denote.
+func (env *Env) Ground(terms []*Term, b Bindings) ([]string, error) {
+	args := make([]string, len(terms))
+	for i, t := range terms {
+		if t.Function == nil {
+			a, err := b.Ground(terms[i : i+1])
+			if err != nil {
+				return nil, err
+			}
+			args[i] = a[0]
+			continue
+		}
+		fargs, err := env.Ground(t.Function.Terms, b)
+		if err != nil {
+			return nil, err
+		}
+		v, ok := env.State.ObjectFluent(t.Function.Name.Name, fargs)
+		if !ok {
+			return nil, &UndefinedFluentError{
+				Key: AtomKey(t.Function.Name.Name, fargs),
+			}
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// Each calls fn for every assignment of the variables to objects of
+// their types, until fn returns true.
+func (env *Env) Each(vars []*TypedEntry, b Bindings, fn func(Bindings) (bool, error)) (bool, error) {
+	if len(vars) == 0 {
+		return fn(b)
+	}
+	for _, o := range env.Universe.OfTypes(vars[0].Types) {
+		stop, err := env.Each(vars[1:], b.With(vars[0].Name.Name, o), fn)
+		if err != nil || stop {
+			return stop, err
+		}
+	}
+	return false, nil
+}
+
+// Evaluate evaluates a goal description; a nil formula holds.
+func Evaluate(f Formula, env *Env, b Bindings) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+	return f.Evaluate(env, b)
+}
+
+// CollectEffects records the changes of an effect; a nil effect
+// changes nothing.
+func CollectEffects(f Formula, env *Env, b Bindings, d *Delta) error {
+	if f == nil {
+		return nil
+	}
+	return f.Collect(env, b, d)
+}
+
+// Apply returns the state reached by applying the effect in the
+// state of the environment.
+func Apply(f Formula, env *Env, b Bindings) (*State, error) {
+	d := &Delta{}
+	err := CollectEffects(f, env, b, d)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(env.State)
+}
+
+func notGoal(f Formula) error {
+	return fmt.Errorf("Failed to evaluate %s: not a goal description", f.ToString(""))
+}
+
+func notEffect(f Formula) error {
+	return fmt.Errorf("Failed to apply %s: not an effect", f.ToString(""))
+}
+
+// Unsatisfied returns the innermost subformula of a false formula
+// that explains why it is false, with the bindings it was evaluated
+// under.
+func Unsatisfied(f Formula, env *Env, b Bindings) (Formula, Bindings) {
+	switch n := f.(type) {
+	case *AndNode:
+		for _, c := range n.MultiNode.Formula {
+			if ok, err := Evaluate(c, env, b); err == nil && !ok {
+				return Unsatisfied(c, env, b)
+			}
+		}
+	case *ImplyNode:
+		return Unsatisfied(n.BinaryNode.Right, env, b)
+	case *ForAllNode:
+		var sub Formula
+		var sb Bindings
+		env.Each(n.QuantNode.Variables, b, func(nb Bindings) (bool, error) {
+			ok, err := Evaluate(n.QuantNode.UnaryNode.Formula, env, nb)
+			if err == nil && !ok {
+				sub, sb = Unsatisfied(n.QuantNode.UnaryNode.Formula, env, nb)
+				return true, nil
+			}
+			return false, err
+		})
+		if sub != nil {
+			return sub, sb
+		}
+	}
+	return f, b
+}
+
+// Evaluate returns false when an object fluent among the terms is
+// undefined.
+func (lit *LiteralNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	args, err := env.Ground(lit.Terms, b)
+	if _, ok := err.(*UndefinedFluentError); ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Failed to evaluate %s: %v", lit.ToString(""), err)
+	}
+	var v bool
+	if lit.Predicate.Name == "=" {
+		if len(args) != 2 {
+			return false, fmt.Errorf("Failed to evaluate %s: equality expects 2 terms", lit.ToString(""))
+		}
+		v = args[0] == args[1]
+	} else {
+		v = env.State.Holds(lit.Predicate.Name, args)
+	}
+	return v != lit.Negative, nil
+}
+
+func (n *AndNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	for _, c := range n.MultiNode.Formula {
+		ok, err := Evaluate(c, env, b)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (n *OrNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	for _, c := range n.MultiNode.Formula {
+		ok, err := Evaluate(c, env, b)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (n *NotNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	ok, err := Evaluate(n.UnaryNode.Formula, env, b)
+	return !ok, err
+}
+
+func (n *ImplyNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	ok, err := Evaluate(n.BinaryNode.Left, env, b)
+	if err != nil || !ok {
+		return true, err
+	}
+	return Evaluate(n.BinaryNode.Right, env, b)
+}
+
+func (n *ForAllNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	failed, err := env.Each(n.QuantNode.Variables, b, func(nb Bindings) (bool, error) {
+		ok, err := Evaluate(n.QuantNode.UnaryNode.Formula, env, nb)
+		return !ok, err
+	})
+	return !failed, err
+}
+
+func (n *ExistsNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	return env.Each(n.QuantNode.Variables, b, func(nb Bindings) (bool, error) {
+		return Evaluate(n.QuantNode.UnaryNode.Formula, env, nb)
+	})
+}
+
+// Evaluate returns false when a fluent of the comparison is
+// undefined, as PDDL 2.1 prescribes.
+func (n *CompareNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	if n.Operation.Name == "=" {
+		// Both sides may be object fluents.
+		l, lok := n.Left.object(env, b)
+		r, rok := n.Right.object(env, b)
+		if lok && rok {
+			return l == r, nil
+		}
+	}
+	l, err := n.Left.Value(env, b)
+	if _, ok := err.(*UndefinedFluentError); ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	r, err := n.Right.Value(env, b)
+	if _, ok := err.(*UndefinedFluentError); ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch n.Operation.Name {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case "=":
+		return l == r, nil
+	case ">=":
+		return l >= r, nil
+	case ">":
+		return l > r, nil
+	}
+	return false, fmt.Errorf("Failed to evaluate %s: unknown comparison", n.ToString(""))
+}
+
+// object returns the object denoted by the expression when it is a
+// defined object fluent.
+func (e *NumericExpression) object(env *Env, b Bindings) (string, bool) {
+	if e.FunctionInit == nil {
+		return "", false
+	}
+	args, err := env.Ground(e.FunctionInit.Terms, b)
+	if err != nil {
+		return "", false
+	}
+	return env.State.ObjectFluent(e.FunctionInit.Name.Name, args)
+}
+
+// Value evaluates the expression to a number.
+func (e *NumericExpression) Value(env *Env, b Bindings) (float64, error) {
+	switch {
+	case e.IsNumber:
+		return e.Number.Float64(), nil
+	case e.FunctionInit != nil:
+		return e.FunctionInit.Value(env, b)
+	}
+	vs := make([]float64, len(e.Operands))
+	for i, o := range e.Operands {
+		v, err := o.Value(env, b)
+		if err != nil {
+			return 0, err
+		}
+		vs[i] = v
+	}
+	switch {
+	case e.Operation.Name == "-" && len(vs) == 1:
+		return -vs[0], nil
+	case len(vs) < 2:
+		return 0, fmt.Errorf("Failed to evaluate %s: expected 2 operands", e.ToString())
+	}
+	r := vs[0]
+	for _, v := range vs[1:] {
+		switch e.Operation.Name {
+		case "+":
+			r += v
+		case "-":
+			r -= v
+		case "*":
+			r *= v
+		case "/":
+			if v == 0 {
+				return 0, fmt.Errorf("Failed to evaluate %s: division by zero", e.ToString())
+			}
+			r /= v
+		}
+	}
+	return r, nil
+}
+
+// Value returns the current value of the ground function head.
+func (h *FunctionInit) Value(env *Env, b Bindings) (float64, error) {
+	args, err := env.Ground(h.Terms, b)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to evaluate %s: %v", h.ToString(), err)
+	}
+	v, ok := env.State.Fluent(h.Name.Name, args)
+	if !ok {
+		if h.Name.Name == "total-time" && len(args) == 0 {
+			return env.Time, nil
+		}
+		if h.Name.Name == "#t" {
+			return env.Step, nil
+		}
+		return 0, &UndefinedFluentError{
+			Key: AtomKey(h.Name.Name, args),
+		}
+	}
+	return v, nil
+}
+
+// UndefinedFluentError is returned when an expression reads a fluent
+// that has no value in the state.
+type UndefinedFluentError struct {
+	Key string
+}
+
+func (e *UndefinedFluentError) Error() string {
+	return fmt.Sprintf("Failed to evaluate %s: fluent is undefined", e.Key)
+}
+
+func (n *AndNode) Collect(env *Env, b Bindings, d *Delta) error {
+	for _, c := range n.MultiNode.Formula {
+		if err := CollectEffects(c, env, b, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lit *LiteralNode) Collect(env *Env, b Bindings, d *Delta) error {
+	args, err := env.Ground(lit.Terms, b)
+	if err != nil {
+		return fmt.Errorf("Failed to apply %s: %v", lit.ToString(""), err)
+	}
+	key := AtomKey(lit.Predicate.Name, args)
+	if lit.Negative {
+		d.Dels = append(d.Dels, key)
+	} else {
+		d.Adds = append(d.Adds, key)
+	}
+	return nil
+}
+
+func (n *ForAllNode) Collect(env *Env, b Bindings, d *Delta) error {
+	_, err := env.Each(n.QuantNode.Variables, b, func(nb Bindings) (bool, error) {
+		return false, CollectEffects(n.QuantNode.UnaryNode.Formula, env, nb, d)
+	})
+	return err
+}
+
+func (n *WhenNode) Collect(env *Env, b Bindings, d *Delta) error {
+	ok, err := Evaluate(n.Condition, env, b)
+	if err != nil || !ok {
+		return err
+	}
+	return CollectEffects(n.UnaryNode.Formula, env, b, d)
+}
+
+func (n *AssignNode) Collect(env *Env, b Bindings, d *Delta) error {
+	args, err := env.Ground(n.AssignedTo.Terms, b)
+	if err != nil {
+		return fmt.Errorf("Failed to apply %s: %v", n.ToString(""), err)
+	}
+	key := AtomKey(n.AssignedTo.Name.Name, args)
+	// An object fluent is assigned a term, or the value of another
+	// object fluent.
+	var obj *Term
+	switch {
+	case n.Term != nil:
+		obj = n.Term
+	case n.FunctionInit != nil:
+		_, defined := env.State.Objects[key]
+		src := &NumericExpression{
+			FunctionInit: n.FunctionInit,
+		}
+		if _, ok := src.object(env, b); ok || defined {
+			obj = &Term{
+				Name:     n.FunctionInit.Name,
+				Function: n.FunctionInit,
+			}
+		}
+	}
+	if obj != nil {
+		v, err := env.Ground([]*Term{obj}, b)
+		if err != nil {
+			return fmt.Errorf("Failed to apply %s: %v", n.ToString(""), err)
+		}
+		d.Updates = append(d.Updates, &FluentUpdate{
+			Operation: n.Operation.Name,
+			Key:       key,
+			IsObject:  true,
+			Object:    v[0],
+		})
+		return nil
+	}
+	var v float64
+	switch {
+	case n.IsNumber:
+		v = n.Number.Float64()
+	case n.Expression != nil:
+		v, err = n.Expression.Value(env, b)
+	default:
+		v, err = n.FunctionInit.Value(env, b)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to apply %s: %v", n.ToString(""), err)
+	}
+	d.Updates = append(d.Updates, &FluentUpdate{
+		Operation: n.Operation.Name,
+		Key:       key,
+		Value:     v,
+	})
+	return nil
+}
+
+func (n *NotNode) Collect(env *Env, b Bindings, d *Delta) error {
+	lit, ok := n.UnaryNode.Formula.(*LiteralNode)
+	if !ok || lit.Negative {
+		return notEffect(n)
+	}
+	neg := *lit
+	neg.Negative = true
+	return neg.Collect(env, b, d)
+}
+
+func (n *OneOfNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	return false, notGoal(n)
+}
+
+// Collect fails: the effect that happens is only known once the
+// action is executed.
+func (n *OneOfNode) Collect(env *Env, b Bindings, d *Delta) error {
+	return fmt.Errorf("Failed to apply %s: effect is non-deterministic", n.ToString(""))
+}
+
+func (n *OrNode) Collect(env *Env, b Bindings, d *Delta) error {
+	return notEffect(n)
+}
+
+func (n *ImplyNode) Collect(env *Env, b Bindings, d *Delta) error {
+	return notEffect(n)
+}
+
+func (n *ExistsNode) Collect(env *Env, b Bindings, d *Delta) error {
+	return notEffect(n)
+}
+
+func (n *CompareNode) Collect(env *Env, b Bindings, d *Delta) error {
+	return notEffect(n)
+}
+
+func (n *TimedInitNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	return false, notGoal(n)
+}
+
+// Collect records the change the timed initialization makes when its
+// time is reached.
+func (n *TimedInitNode) Collect(env *Env, b Bindings, d *Delta) error {
+	return CollectEffects(n.UnaryNode.Formula, env, b, d)
+}
+
+func (n *WhenNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	return false, notGoal(n)
+}
+
+func (n *AssignNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	return false, notGoal(n)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 )
 
 func toStringReqs(reqs []*Name) string {
@@ -10,7 +11,7 @@ func toStringReqs(reqs []*Name) string {
 	}
 	s := fmt.Sprintf("%s(:requirements\n", Indent(1))
 	for i, r := range reqs {
-		sTemp := r.Name
+		sTemp := r.Text()
 		if i == len(reqs)-1 {
 			sTemp += ")"
 		}
@@ -25,7 +26,7 @@ func toJSONReqs(reqs []*Name) string {
 	}
 	s := "\"requirements\":{"
 	for i, r := range reqs {
-		sTemp := "\"" + r.Name + "\""
+		sTemp := "\"" + r.Text() + "\""
 		if i == len(reqs) - 1 {
 			sTemp += "},"
 		} else {
@@ -76,9 +77,15 @@ func toStringConsts(def string, cs []*TypedEntry) string {
 	if len(cs) == 0 {
 		return ""
 	}
+	public, private := splitPrivate(cs)
 	var s string
 	s += fmt.Sprintf("%s(%s", Indent(1), def)
-	s += toStringTypedNames("\n"+Indent(2), cs)
+	s += toStringTypedNames("\n"+Indent(2), public)
+	if len(private) > 0 {
+		s += fmt.Sprintf("\n%s(:private", Indent(2))
+		s += toStringTypedNames("\n"+Indent(3), private)
+		s += ")"
+	}
 	s += ")\n"
 	return s
 }
@@ -87,13 +94,33 @@ func toJSONConsts(def string, cs []*TypedEntry) string {
 	if len(cs) == 0 {
 		return ""
 	}
+	public, private := splitPrivate(cs)
 	var s string
 	s += fmt.Sprintf("\"%s\":{", def)
-	s += toJSONTypedNames("", cs)
+	s += toJSONTypedNames("", public)
+	if len(private) > 0 {
+		if len(public) > 0 {
+			s += ","
+		}
+		s += "\"private\":{" + toJSONTypedNames("", private) + "}"
+	}
 	s += "},"
 	return s
 }
 
+// splitPrivate separates the public entries from the MA-PDDL private
+// ones.
+func splitPrivate(tes []*TypedEntry) (public []*TypedEntry, private []*TypedEntry) {
+	for _, te := range tes {
+		if te.Private {
+			private = append(private, te)
+		} else {
+			public = append(public, te)
+		}
+	}
+	return public, private
+}
+
 
 func toStringPredicates(ps []*Predicate) string {
 	var s string
@@ -101,17 +128,32 @@ func toStringPredicates(ps []*Predicate) string {
 		return ""
 	}
 	s += fmt.Sprintf("%s(:predicates\n", Indent(1))
+	private := []*Predicate{}
 	for i, p := range ps {
 		if p.Name.Location.Line == 0 {
 			continue
 		}
-		s += fmt.Sprintf("%s(%s", Indent(2), p.Name.Name)
+		if p.Private {
+			private = append(private, p)
+			continue
+		}
+		s += fmt.Sprintf("%s(%s", Indent(2), p.Name.Text())
 		s += toStringTypedNames(" ", p.Parameters)
 		s += ")"
 		if i < len(ps)-1 {
 			s += "\n"
 		}
 	}
+	if len(private) > 0 {
+		s = strings.TrimSuffix(s, "\n")
+		s += fmt.Sprintf("\n%s(:private", Indent(2))
+		for _, p := range private {
+			s += fmt.Sprintf("\n%s(%s", Indent(3), p.Name.Text())
+			s += toStringTypedNames(" ", p.Parameters)
+			s += ")"
+		}
+		s += ")"
+	}
 	s += ")\n"
 	return s
 }
@@ -126,8 +168,14 @@ func toJSONPredicates(ps []*Predicate) string {
 		if p.Name.Location.Line == 0 {
 			continue
 		}
-		s += fmt.Sprintf("\"%s\":{", p.Name.Name)
+		s += fmt.Sprintf("\"%s\":{", p.Name.Text())
 		s += toJSONTypedNames("", p.Parameters)
+		if p.Private && len(p.Parameters) > 0 {
+			s += ","
+		}
+		if p.Private {
+			s += "\"private\":true"
+		}
 		if i == len(ps) - 1 {
 			s += "}"
 		} else {
@@ -145,17 +193,17 @@ func toStringFunctions(fs []*Function) string {
 	}
 	s += fmt.Sprintf("%s(:functions\n", Indent(1))
 	for i, f := range fs {
-		s += fmt.Sprintf("%s(%s", Indent(2), f.Name.Name)
+		s += fmt.Sprintf("%s(%s", Indent(2), f.Name.Text())
 		s += toStringTypedNames(" ", f.Params)
 		s += ")"
 		if len(f.Types) > 0 {
-			s += fmt.Sprintf(" - %s\n", toStringType(f.Types))
+			s += fmt.Sprintf(" - %s", toStringType(f.Types))
 		}
 		if i < len(fs)-1 {
 			s += "\n"
 		}
 	}
-	s += fmt.Sprintf(")")
+	s += ")\n"
 	return s
 }
 
@@ -166,7 +214,7 @@ func toJSONFunctions(fs []*Function) string {
 	}
 	s += "\"functions\":{"
 	for _, f := range fs {
-		s += fmt.Sprintf("\"%s\":", f.Name.Name)
+		s += fmt.Sprintf("\"%s\":", f.Name.Text())
 		s += toJSONTypedNames("", f.Params)
 		s += "},"
 		if len(f.Types) > 0 {
@@ -177,11 +225,16 @@ func toJSONFunctions(fs []*Function) string {
 	return s
 }
 
-func toStringAction(act *Action) string {
+func toStringAction(kind string, act *Action) string {
 	var s string
-	s += fmt.Sprintf("%s(:action %s\n", Indent(1), act.Name.Name)
+	s += fmt.Sprintf("%s(%s %s\n", Indent(1), kind, act.Name.Text())
+	params := act.Params
+	if act.Agent {
+		s += fmt.Sprintf("%s:agent %s\n", Indent(2), toStringTypedNames("", params[:1]))
+		params = params[1:]
+	}
 	s += fmt.Sprintf("%s:parameters (", Indent(2))
-	s += toStringTypedNames("", act.Params)
+	s += toStringTypedNames("", params)
 	s += ")"
 	if act.Precondition != nil {
 		s += "\n"
@@ -205,11 +258,16 @@ func toStringAction(act *Action) string {
 	return s
 }
 
-func toJSONAction(act *Action) string {
+func toJSONAction(kind string, act *Action) string {
 	var s string
-	s += fmt.Sprintf("\"action\":{\"%s\":{", act.Name.Name)
+	s += fmt.Sprintf("\"%s\":{\"%s\":{", kind, act.Name.Text())
+	params := act.Params
+	if act.Agent {
+		s += "\"agent\":{" + toJSONTypedNames("", params[:1]) + "},"
+		params = params[1:]
+	}
 	s += "\"parameters\":{"
-	s += toJSONTypedNames("", act.Params)
+	s += toJSONTypedNames("", params)
 	s += "},"
 	if act.Precondition != nil {
 		s += "\"precondition\":{"
@@ -249,7 +307,7 @@ func toStringTypedNames(prefix string, ns []*TypedEntry) string {
 				sep = " "
 			}
 		}
-		s += fmt.Sprintf("%s%s", sep, n.Name.Name)
+		s += fmt.Sprintf("%s%s", sep, n.Name.Text())
 		sep = " "
 	}
 	if tprev != "" {
@@ -276,9 +334,9 @@ func toJSONTypedNames(prefix string, ns []*TypedEntry) string {
 			tprev = tcur
 		}
 		if i == len(ns) - 1 {
-			s += fmt.Sprintf("\"%s - %s\"", n.Name.Name, tcur)
+			s += fmt.Sprintf("\"%s - %s\"", n.Name.Text(), tcur)
 		} else {
-			s += fmt.Sprintf("\"%s - %s\",", n.Name.Name, tcur)
+			s += fmt.Sprintf("\"%s - %s\",", n.Name.Text(), tcur)
 		}
 	}
 	return s
@@ -293,11 +351,11 @@ func toStringType(t []*TypeName) string {
 		if t[0].Name.Location.Line == 0 {
 			break
 		}
-		str = t[0].Name.Name
+		str = t[0].Name.Text()
 	default:
 		str = "(either"
 		for _, n := range t {
-			str += " " + n.Name.Name
+			str += " " + n.Name.Text()
 		}
 		str += ")"
 	}
@@ -313,14 +371,14 @@ func toJSONType(t []*TypeName) string {
 		if t[0].Name.Location.Line == 0 {
 			break
 		}
-		str = t[0].Name.Name
+		str = t[0].Name.Text()
 	default:
 		str = "\"either\":{"
 		for i, n := range t {
 			if i == len(t) - 1 {
-				str += "\"" + n.Name.Name + "\""
+				str += "\"" + n.Name.Text() + "\""
 			} else {
-				str += "\"" + n.Name.Name + "\","
+				str += "\"" + n.Name.Text() + "\","
 			}
 		}
 		str += "},"
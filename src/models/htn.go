@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Task is an abstract HDDL task, decomposed by methods.
+type Task struct {
+	Name   *Name
+	Params []*TypedEntry
+}
+
+// Subtask is an occurrence of a task or action in a task network,
+// e.g. (task0 (load ?v ?l ?p)). Id is nil for anonymous subtasks.
+type Subtask struct {
+	Node  *Node
+	Id    *Name
+	Task  *Name
+	Terms []*Term
+}
+
+// Ordering states that the subtask First comes before Second.
+type Ordering struct {
+	Node   *Node
+	First  *Name
+	Second *Name
+}
+
+// TaskNetwork is a partially ordered set of subtasks. Ordered is set
+// by :ordered-subtasks, in which case the subtasks are totally
+// ordered as listed and Orderings is empty.
+type TaskNetwork struct {
+	Params    []*TypedEntry
+	Subtasks  []*Subtask
+	Ordered   bool
+	Orderings []*Ordering
+}
+
+// Method decomposes the task it refines into a task network.
+type Method struct {
+	Name         *Name
+	Params       []*TypedEntry
+	Task         *Name
+	TaskTerms    []*Term
+	Precondition Formula
+	Network      *TaskNetwork
+}
+
+func (st *Subtask) ToString() string {
+	s := "(" + st.Task.Text()
+	for _, t := range st.Terms {
+		s += " " + t.ToString()
+	}
+	s += ")"
+	if st.Id != nil {
+		s = fmt.Sprintf("(%s %s)", st.Id.Text(), s)
+	}
+	return s
+}
+
+func (st *Subtask) ToJSON() string {
+	terms := []string{}
+	for _, t := range st.Terms {
+		terms = append(terms, t.ToJSON())
+	}
+	s := fmt.Sprintf("\"%s\":[%s]", st.Task.Text(), strings.Join(terms, ","))
+	if st.Id != nil {
+		s = fmt.Sprintf("\"%s\":{%s}", st.Id.Text(), s)
+	}
+	return s
+}
+
+func (o *Ordering) ToString() string {
+	return fmt.Sprintf("(< %s %s)", o.First.Text(), o.Second.Text())
+}
+
+func (o *Ordering) ToJSON() string {
+	return fmt.Sprintf("[\"%s\",\"%s\"]", o.First.Text(), o.Second.Text())
+}
+
+// toString prints the sections of the network, each on its own line
+// at the indentation.
+func (tn *TaskNetwork) toString(prefix string) string {
+	var s string
+	kw := ":subtasks"
+	if tn.Ordered {
+		kw = ":ordered-subtasks"
+	}
+	s += fmt.Sprintf("%s%s", prefix, kw)
+	switch len(tn.Subtasks) {
+	case 0:
+		s += " ()"
+	case 1:
+		s += " " + tn.Subtasks[0].ToString()
+	default:
+		s += fmt.Sprintf("\n%s(and", prefix+Indent(1))
+		for _, st := range tn.Subtasks {
+			s += fmt.Sprintf("\n%s%s", prefix+Indent(2), st.ToString())
+		}
+		s += ")"
+	}
+	if len(tn.Orderings) > 0 {
+		s += fmt.Sprintf("\n%s:ordering", prefix)
+		if len(tn.Orderings) == 1 {
+			s += " " + tn.Orderings[0].ToString()
+		} else {
+			s += fmt.Sprintf("\n%s(and", prefix+Indent(1))
+			for _, o := range tn.Orderings {
+				s += fmt.Sprintf("\n%s%s", prefix+Indent(2), o.ToString())
+			}
+			s += ")"
+		}
+	}
+	return s
+}
+
+func (tn *TaskNetwork) ToJSON() string {
+	sts := []string{}
+	for _, st := range tn.Subtasks {
+		sts = append(sts, "{"+st.ToJSON()+"}")
+	}
+	os := []string{}
+	for _, o := range tn.Orderings {
+		os = append(os, o.ToJSON())
+	}
+	return fmt.Sprintf("\"ordered\":%t,\"subtasks\":[%s],\"ordering\":[%s]", tn.Ordered, strings.Join(sts, ","), strings.Join(os, ","))
+}
+
+func toStringTask(t *Task) string {
+	s := fmt.Sprintf("%s(:task %s\n", Indent(1), t.Name.Text())
+	s += fmt.Sprintf("%s:parameters (%s))\n", Indent(2), toStringTypedNames("", t.Params))
+	return s
+}
+
+func toJSONTask(t *Task) string {
+	return fmt.Sprintf("\"task\":{\"%s\":{\"parameters\":{%s}}},", t.Name.Text(), toJSONTypedNames("", t.Params))
+}
+
+func toStringMethod(m *Method) string {
+	s := fmt.Sprintf("%s(:method %s\n", Indent(1), m.Name.Text())
+	s += fmt.Sprintf("%s:parameters (%s)\n", Indent(2), toStringTypedNames("", m.Params))
+	task := &Subtask{
+		Task:  m.Task,
+		Terms: m.TaskTerms,
+	}
+	s += fmt.Sprintf("%s:task %s\n", Indent(2), task.ToString())
+	if m.Precondition != nil {
+		s += fmt.Sprintf("%s:precondition\n", Indent(2))
+		s += m.Precondition.ToString(Indent(3))
+		s += "\n"
+	}
+	s += m.Network.toString(Indent(2))
+	s += ")\n"
+	return s
+}
+
+func toJSONMethod(m *Method) string {
+	task := &Subtask{
+		Task:  m.Task,
+		Terms: m.TaskTerms,
+	}
+	s := fmt.Sprintf("\"method\":{\"%s\":{", m.Name.Text())
+	s += "\"parameters\":{" + toJSONTypedNames("", m.Params) + "},"
+	s += "\"task\":{" + task.ToJSON() + "},"
+	if m.Precondition != nil {
+		s += "\"precondition\":{" + m.Precondition.ToJSON("") + "},"
+	}
+	s += m.Network.ToJSON()
+	s += "}},"
+	return s
+}
+
+// toStringHtn prints the initial task network of a problem.
+func toStringHtn(tn *TaskNetwork) string {
+	s := fmt.Sprintf("%s(:htn\n", Indent(1))
+	if len(tn.Params) > 0 {
+		s += fmt.Sprintf("%s:parameters (%s)\n", Indent(2), toStringTypedNames("", tn.Params))
+	}
+	s += tn.toString(Indent(2))
+	s += ")\n"
+	return s
+}
+
+func toJSONHtn(tn *TaskNetwork) string {
+	return "\"htn\":{\"parameters\":{" + toJSONTypedNames("", tn.Params) + "}," + tn.ToJSON() + "},"
+}
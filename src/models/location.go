@@ -5,6 +5,9 @@ import "fmt"
 type Location struct {
 	Path string
 	Line int
+	// Offset is the position in bytes in the file, which maps the
+	// model back to its concrete syntax tree.
+	Offset int
 }
 
 func (l *Location) ToString() (string, error) {
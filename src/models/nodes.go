@@ -2,19 +2,43 @@ package models
 
 import (
 	"fmt"
+	"strings"
 )
 
 var (
 	AssignOps = map[string]bool{
-		"=":        true,
-		"assign":   true,
-		"increase": true,
+		"=":          true,
+		"assign":     true,
+		"increase":   true,
+		"decrease":   true,
+		"scale-up":   true,
+		"scale-down": true,
+	}
+
+	CompareOps = map[string]bool{
+		"<":  true,
+		"<=": true,
+		"=":  true,
+		">=": true,
+		">":  true,
+	}
+
+	ArithmeticOps = map[string]bool{
+		"+": true,
+		"-": true,
+		"*": true,
+		"/": true,
 	}
 )
 
+// Formula is any node of a goal description, effect or init
+// element. Evaluating an effect or collecting a goal description
+// returns an error.
 type Formula interface {
 	ToString(string) string
 	ToJSON(string) string
+	Evaluable
+	Effect
 }
 
 type Node struct {
@@ -59,6 +83,12 @@ type OrNode struct {
 	MultiNode *MultiNode
 }
 
+// OneOfNode is a FOND non-deterministic effect: exactly one of the
+// effects happens, without known probabilities.
+type OneOfNode struct {
+	MultiNode *MultiNode
+}
+
 type NotNode struct {
 	UnaryNode *UnaryNode
 }
@@ -81,16 +111,44 @@ type WhenNode struct {
 	UnaryNode *UnaryNode
 }
 
+// TimedInitNode is a timed initial literal or fluent initialization
+// such as (at 10.5 (open door1)): the formula becomes true at Time.
+type TimedInitNode struct {
+	Node      *Node
+	Time      *Number
+	UnaryNode *UnaryNode
+}
+
 type AssignNode struct {
 	Node         *Node
 	Operation    *Name
 	AssignedTo   *FunctionInit
 	IsNumber     bool
-	Number       string
+	Number       *Number
 	FunctionInit *FunctionInit
+	Expression   *NumericExpression
+	Term         *Term
 	IsInit       bool
 }
 
+// NumericExpression is either a number, a function head or an
+// arithmetic operation over nested expressions.
+type NumericExpression struct {
+	Node         *Node
+	IsNumber     bool
+	Number       *Number
+	FunctionInit *FunctionInit
+	Operation    *Name
+	Operands     []*NumericExpression
+}
+
+type CompareNode struct {
+	Node      *Node
+	Operation *Name
+	Left      *NumericExpression
+	Right     *NumericExpression
+}
+
 func (lit *LiteralNode) ToString(prefix string) string {
 	var s string
 	if lit.Negative {
@@ -98,9 +156,9 @@ func (lit *LiteralNode) ToString(prefix string) string {
 		prefix = ""
 	}
 	s += fmt.Sprintf("%s(", prefix)
-	s += lit.Predicate.Name
+	s += lit.Predicate.Text()
 	for _, t := range lit.Terms {
-		s += fmt.Sprintf(" %s", t.Name.Name)
+		s += fmt.Sprintf(" %s", t.ToString())
 	}
 	s += ")"
 	if lit.Negative {
@@ -114,12 +172,12 @@ func (lit *LiteralNode) ToJSON(prefix string) string {
 	if lit.Negative {
 		s += "\"not\":{"
 	}
-	s += "\"" + lit.Predicate.Name + "\"" + ":{"
+	s += "\"" + lit.Predicate.Text() + "\"" + ":{"
 	for i, t := range lit.Terms {
 		if i == len(lit.Terms) - 1 {
-			s += fmt.Sprintf("\"%s\"", t.Name.Name)
+			s += t.ToJSON()
 		} else {
-			s += fmt.Sprintf("\"%s\",", t.Name.Name)
+			s += t.ToJSON() + ","
 		}
 	}
 	s += "},"
@@ -174,9 +232,28 @@ func (n *OrNode) ToJSON(prefix string) string {
 	return s
 }
 
+func (n *OneOfNode) ToString(prefix string) string {
+	s := fmt.Sprintf("%s(oneof", prefix)
+	for _, f := range n.MultiNode.Formula {
+		s += "\n"
+		s += f.ToString(prefix + Indent(1))
+	}
+	s += ")"
+	return s
+}
+
+func (n *OneOfNode) ToJSON(prefix string) string {
+	s := "\"oneof\":["
+	for _, f := range n.MultiNode.Formula {
+		s += "{" + f.ToJSON("") + "},"
+	}
+	s += "],"
+	return s
+}
+
 func (n *NotNode) ToString(prefix string) string {
-	s := fmt.Sprintf("%s(not", prefix)
-	s += n.UnaryNode.Formula.ToString(prefix)
+	s := fmt.Sprintf("%s(not ", prefix)
+	s += n.UnaryNode.Formula.ToString("")
 	s += ")"
 	return s
 }
@@ -259,11 +336,29 @@ func (n *WhenNode) ToJSON(prefix string) string {
 	return s
 }
 
+func (n *TimedInitNode) ToString(prefix string) string {
+	return fmt.Sprintf("%s(at %s %s)", prefix, n.Time.Text, n.UnaryNode.Formula.ToString(""))
+}
+
+func (n *TimedInitNode) ToJSON(prefix string) string {
+	s := "\"at\":{"
+	s += "\"time\":\"" + n.Time.Text + "\","
+	s += n.UnaryNode.Formula.ToJSON("")
+	s += "},"
+	return s
+}
+
 func (n *AssignNode) ToString(prefix string) string {
-	s := fmt.Sprintf("%s(%s ", prefix, n.Operation.Name)
+	s := fmt.Sprintf("%s(%s ", prefix, n.Operation.Text())
 	s += n.AssignedTo.ToString()
 	if n.IsNumber {
-		s += fmt.Sprintf(" %s", n.Number)
+		s += fmt.Sprintf(" %s", n.Number.Text)
+	} else if n.Term != nil {
+		s += " "
+		s += n.Term.ToString()
+	} else if n.Expression != nil {
+		s += " "
+		s += n.Expression.ToString()
 	} else {
 		s += " "
 		s += n.FunctionInit.ToString()
@@ -273,10 +368,14 @@ func (n *AssignNode) ToString(prefix string) string {
 }
 
 func (n *AssignNode) ToJSON(prefix string) string {
-	s := "\"" + n.Operation.Name + "\":{"
+	s := "\"" + n.Operation.Text() + "\":{"
 	s += n.AssignedTo.ToJSON()
 	if n.IsNumber {
-		s += "\"" + n.Number + "\""
+		s += "\"" + n.Number.Text + "\""
+	} else if n.Term != nil {
+		s += n.Term.ToJSON()
+	} else if n.Expression != nil {
+		s += n.Expression.ToJSON()
 	} else {
 		s += n.FunctionInit.ToJSON()
 	}
@@ -284,15 +383,77 @@ func (n *AssignNode) ToJSON(prefix string) string {
 	return s
 }
 
+func (n *CompareNode) ToString(prefix string) string {
+	return fmt.Sprintf("%s(%s %s %s)", prefix, n.Operation.Text(), n.Left.ToString(), n.Right.ToString())
+}
+
+func (n *CompareNode) ToJSON(prefix string) string {
+	s := "\"" + n.Operation.Text() + "\":{"
+	s += n.Left.ToJSON() + ","
+	s += n.Right.ToJSON()
+	s += "}"
+	return s
+}
+
+func (e *NumericExpression) ToString() string {
+	switch {
+	case e.IsNumber:
+		return e.Number.Text
+	case e.FunctionInit != nil:
+		return e.FunctionInit.ToString()
+	}
+	s := "(" + e.Operation.Text()
+	for _, o := range e.Operands {
+		s += " " + o.ToString()
+	}
+	s += ")"
+	return s
+}
+
+func (e *NumericExpression) ToJSON() string {
+	switch {
+	case e.IsNumber:
+		return "\"" + e.Number.Text + "\""
+	case e.FunctionInit != nil:
+		return "{" + strings.TrimSuffix(e.FunctionInit.ToJSON(), ",") + "}"
+	}
+	s := "{\"" + e.Operation.Text() + "\":["
+	for i, o := range e.Operands {
+		if i > 0 {
+			s += ","
+		}
+		s += o.ToJSON()
+	}
+	s += "]}"
+	return s
+}
+
+func (t *Term) ToString() string {
+	if t.Function != nil {
+		return t.Function.ToString()
+	}
+	return t.Name.Text()
+}
+
+func (t *Term) ToJSON() string {
+	if t.Function != nil {
+		return "{" + strings.TrimSuffix(t.Function.ToJSON(), ",") + "}"
+	}
+	return "\"" + t.Name.Text() + "\""
+}
+
 func (h *FunctionInit) ToString() string {
 	var s string
+	if h.Name.Name == "#t" {
+		return h.Name.Text()
+	}
 	if len(h.Terms) == 0 {
-		s += fmt.Sprintf("(%s)", h.Name.Name)
+		s += fmt.Sprintf("(%s)", h.Name.Text())
 		return s
 	}
-	s += fmt.Sprintf("(%s", h.Name.Name)
+	s += fmt.Sprintf("(%s", h.Name.Text())
 	for _, t := range h.Terms {
-		s += fmt.Sprintf(" %s", t.Name.Name)
+		s += fmt.Sprintf(" %s", t.ToString())
 	}
 	s += ")"
 	return s
@@ -301,15 +462,15 @@ func (h *FunctionInit) ToString() string {
 func (h *FunctionInit) ToJSON() string {
 	var s string
 	if len(h.Terms) == 0 {
-		s += fmt.Sprintf("\"%s\":{},", h.Name.Name)
+		s += fmt.Sprintf("\"%s\":{},", h.Name.Text())
 		return s
 	}
-	s += fmt.Sprintf("\"%s\":{", h.Name.Name)
+	s += fmt.Sprintf("\"%s\":{", h.Name.Text())
 	for i := range h.Terms {
 		if i == len(h.Terms) - 1 {
-			s += fmt.Sprintf("%s", h.Terms[i].Name.Name)
+			s += fmt.Sprintf("%s", h.Terms[i].Name.Text())
 		} else {
-			s += fmt.Sprintf("%s,", h.Terms[i].Name.Name)
+			s += fmt.Sprintf("%s,", h.Terms[i].Name.Text())
 		}
 	}
 	s += "},"
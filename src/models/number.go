@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExactExponent is the largest decimal exponent of a number kept
+// exact, beyond which rationals grow too large to be practical.
+const maxExactExponent = 1000
+
+// Number is a numeric literal, such as 12, -0.5 or 1.5e-3. Its value is
+// exact, as a rational, unless its exponent is too large for that, in
+// which case it is the closest float64. Numbers too large for a float64
+// are errors.
+type Number struct {
+	// Text is the literal as written.
+	Text  string
+	Rat   *big.Rat
+	Float float64
+}
+
+// NewNumber parses a numeric literal.
+func NewNumber(text string) (*Number, error) {
+	n := &Number{
+		Text: text,
+	}
+	exp := 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		e, err := strconv.Atoi(strings.TrimPrefix(text[i+1:], "+"))
+		if err != nil {
+			exp = maxExactExponent + 1
+		} else {
+			exp = e
+		}
+	}
+	if exp >= -maxExactExponent && exp <= maxExactExponent {
+		r, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil, fmt.Errorf("Failed to parse number %s", text)
+		}
+		n.Rat = r
+		n.Float, _ = r.Float64()
+		if math.IsInf(n.Float, 0) {
+			return nil, fmt.Errorf("Failed to parse number %s: value out of range", text)
+		}
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	// Numbers too close to zero are rounded to it, too large ones are
+	// errors.
+	if err != nil && (!errors.Is(err, strconv.ErrRange) || f != 0) {
+		return nil, fmt.Errorf("Failed to parse number %s: %v", text, err)
+	}
+	n.Float = f
+	return n, nil
+}
+
+// IsExact tells whether the value of the number is exact.
+func (n *Number) IsExact() bool {
+	return n.Rat != nil
+}
+
+// Float64 returns the value of the number, rounded when it isn't a
+// float64.
+func (n *Number) Float64() float64 {
+	return n.Float
+}
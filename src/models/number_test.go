@@ -0,0 +1,48 @@
+package models_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+func TestNewNumber(t *testing.T) {
+	tests := []struct {
+		text  string
+		exact string
+		value float64
+	}{
+		{"12", "12", 12},
+		{"-0.5", "-1/2", -0.5},
+		{"1.5e3", "1500", 1500},
+		{"0.1", "1/10", 0.1},
+		{"1e-3", "1/1000", 0.001},
+		{"1e-2000", "", 0},
+	}
+	for _, test := range tests {
+		n, err := models.NewNumber(test.text)
+		if err != nil {
+			t.Errorf("%s: %v", test.text, err)
+			continue
+		}
+		if test.exact == "" {
+			if n.IsExact() || n.Float64() != test.value {
+				t.Errorf("%s: got exact %t, value %g", test.text, n.IsExact(), n.Float64())
+			}
+			continue
+		}
+		want, _ := new(big.Rat).SetString(test.exact)
+		if !n.IsExact() || n.Rat.Cmp(want) != 0 || n.Float64() != test.value {
+			t.Errorf("%s: got %v, value %g, want %s", test.text, n.Rat, n.Float64(), test.exact)
+		}
+	}
+}
+
+func TestNewNumberErrors(t *testing.T) {
+	for _, text := range []string{"1e999", "1e5000", "abc", ""} {
+		if _, err := models.NewNumber(text); err == nil {
+			t.Errorf("%q: got no error", text)
+		}
+	}
+}
@@ -1,3 +1,113 @@
 package models
 
-type Plan []Formula
+import (
+	"fmt"
+	"strings"
+)
+
+// Plan is a sequence of ground actions, as written by IPC planners.
+// Cost is the value of the "; cost = N" comment, empty when absent.
+type Plan struct {
+	Steps    []*PlanStep
+	Cost     string
+	UnitCost bool
+}
+
+// PlanStep is one line of a plan file. Time and Duration are only
+// set for temporal plans ("0.000: (move a b) [2.000]").
+type PlanStep struct {
+	Node       *Node
+	Action     *Name
+	Terms      []*Term
+	IsTimed    bool
+	Time       string
+	Duration   string
+	Definition *Action
+}
+
+func (pl *Plan) IsTemporal() bool {
+	for _, st := range pl.Steps {
+		if st.IsTimed {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *PlanStep) Arguments() []string {
+	args := make([]string, len(st.Terms))
+	for i, t := range st.Terms {
+		args[i] = t.Name.Name
+	}
+	return args
+}
+
+func (st *PlanStep) ToString() string {
+	s := AtomKey(st.Action.Name, st.Arguments())
+	if st.IsTimed {
+		s = st.Time + ": " + s
+	}
+	if st.Duration != "" {
+		s += " [" + st.Duration + "]"
+	}
+	return s
+}
+
+func (st *PlanStep) ToJSON() string {
+	s := fmt.Sprintf("{\"action\":\"%s\",\"arguments\":[", st.Action.Name)
+	for i, a := range st.Arguments() {
+		if i > 0 {
+			s += ","
+		}
+		s += "\"" + a + "\""
+	}
+	s += "]"
+	if st.IsTimed {
+		s += fmt.Sprintf(",\"time\":\"%s\"", st.Time)
+	}
+	if st.Duration != "" {
+		s += fmt.Sprintf(",\"duration\":\"%s\"", st.Duration)
+	}
+	s += "}"
+	return s
+}
+
+// ToString writes the plan in the IPC plan file format.
+func (pl *Plan) ToString() string {
+	var sb strings.Builder
+	for _, st := range pl.Steps {
+		sb.WriteString(st.ToString())
+		sb.WriteString("\n")
+	}
+	if pl.Cost != "" {
+		kind := "general cost"
+		if pl.UnitCost {
+			kind = "unit cost"
+		}
+		sb.WriteString(fmt.Sprintf("; cost = %s (%s)\n", pl.Cost, kind))
+	}
+	return sb.String()
+}
+
+func (pl *Plan) ToJSON() string {
+	s := "{\"steps\":["
+	for i, st := range pl.Steps {
+		if i > 0 {
+			s += ","
+		}
+		s += st.ToJSON()
+	}
+	s += "]"
+	if pl.Cost != "" {
+		s += fmt.Sprintf(",\"cost\":\"%s\",\"unit_cost\":%t", pl.Cost, pl.UnitCost)
+	}
+	s += "}"
+	return s
+}
+
+func (pl *Plan) PrintPlan() {
+	if pl == nil {
+		panic("Plan is nil, can't print")
+	}
+	fmt.Print(pl.ToString())
+}
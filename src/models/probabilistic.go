@@ -0,0 +1,254 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// ProbabilisticNode is a PPDDL effect (probabilistic p1 e1 ... pn en):
+// effect ei happens with probability pi, and nothing happens with the
+// remaining probability.
+type ProbabilisticNode struct {
+	Node     *Node
+	Outcomes []*ProbabilisticOutcome
+}
+
+type ProbabilisticOutcome struct {
+	Probability *Number
+	Formula     Formula
+}
+
+// Outcome is one possible ground change of an action, with its
+// probability. Changes of the PPDDL reward fluent are summed into
+// Reward rather than kept in the delta.
+type Outcome struct {
+	Probability float64
+	Delta       *Delta
+	Reward      float64
+}
+
+// probabilityEpsilon absorbs the rounding of decimal probabilities.
+const probabilityEpsilon = 1e-9
+
+// Probabilities returns the probability of every outcome, checking
+// that each is in [0, 1] and that they sum to at most 1.
+func (n *ProbabilisticNode) Probabilities() ([]float64, error) {
+	ps := make([]float64, len(n.Outcomes))
+	sum := 0.0
+	for i, o := range n.Outcomes {
+		p := o.Probability.Float64()
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("probability %s isn't between 0 and 1", o.Probability.Text)
+		}
+		ps[i] = p
+		sum += p
+	}
+	if sum > 1+probabilityEpsilon {
+		return nil, fmt.Errorf("probabilities sum to %s, more than 1", strconv.FormatFloat(sum, 'g', -1, 64))
+	}
+	return ps, nil
+}
+
+// Remainder returns the probability that no outcome happens, which is
+// exact when the probabilities are and otherwise rounded to 0 when
+// below probabilityEpsilon.
+func (n *ProbabilisticNode) Remainder() (float64, error) {
+	ps, err := n.Probabilities()
+	if err != nil {
+		return 0, err
+	}
+	rest := big.NewRat(1, 1)
+	for _, o := range n.Outcomes {
+		if !o.Probability.IsExact() {
+			rest = nil
+			break
+		}
+		rest.Sub(rest, o.Probability.Rat)
+	}
+	if rest != nil {
+		r, _ := rest.Float64()
+		return r, nil
+	}
+	r := 1.0
+	for _, p := range ps {
+		r -= p
+	}
+	if r <= probabilityEpsilon {
+		return 0, nil
+	}
+	return r, nil
+}
+
+func (n *ProbabilisticNode) ToString(prefix string) string {
+	s := fmt.Sprintf("%s(probabilistic", prefix)
+	for _, o := range n.Outcomes {
+		s += fmt.Sprintf("\n%s%s\n", prefix+Indent(1), o.Probability.Text)
+		s += o.Formula.ToString(prefix + Indent(1))
+	}
+	s += ")"
+	return s
+}
+
+func (n *ProbabilisticNode) ToJSON(prefix string) string {
+	s := "\"probabilistic\":{"
+	for _, o := range n.Outcomes {
+		s += "\"" + o.Probability.Text + "\":{"
+		s += o.Formula.ToJSON("")
+		s += "},"
+	}
+	s += "},"
+	return s
+}
+
+func (n *ProbabilisticNode) Children() []Formula {
+	fs := make([]Formula, len(n.Outcomes))
+	for i, o := range n.Outcomes {
+		fs[i] = o.Formula
+	}
+	return fs
+}
+
+func (n *ProbabilisticNode) SetChildren(fs []Formula) {
+	for i, f := range fs {
+		n.Outcomes[i].Formula = f
+	}
+}
+
+func (n *ProbabilisticNode) Evaluate(env *Env, b Bindings) (bool, error) {
+	return false, notGoal(n)
+}
+
+// Collect fails: a probabilistic effect has no single change, see
+// Outcomes.
+func (n *ProbabilisticNode) Collect(env *Env, b Bindings, d *Delta) error {
+	return fmt.Errorf("Failed to apply %s: effect is probabilistic", n.ToString(""))
+}
+
+// Outcomes enumerates the possible changes of an effect and their
+// probabilities. Deterministic effects have a single outcome of
+// probability 1; outcomes of independent probabilistic effects are
+// combined.
+func Outcomes(f Formula, env *Env, b Bindings) ([]*Outcome, error) {
+	outs, err := outcomes(f, env, b)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range outs {
+		updates := []*FluentUpdate{}
+		for _, u := range o.Delta.Updates {
+			switch {
+			case u.Key == "(reward)" && u.Operation == "increase":
+				o.Reward += u.Value
+			case u.Key == "(reward)" && u.Operation == "decrease":
+				o.Reward -= u.Value
+			default:
+				updates = append(updates, u)
+			}
+		}
+		o.Delta.Updates = updates
+	}
+	return outs, nil
+}
+
+func certain(d *Delta) []*Outcome {
+	return []*Outcome{
+		{
+			Probability: 1,
+			Delta:       d,
+		},
+	}
+}
+
+// combine returns the outcomes of two independent effects.
+func combine(as []*Outcome, bs []*Outcome) []*Outcome {
+	outs := []*Outcome{}
+	for _, a := range as {
+		for _, b := range bs {
+			outs = append(outs, &Outcome{
+				Probability: a.Probability * b.Probability,
+				Delta: &Delta{
+					Adds:    append(append([]string{}, a.Delta.Adds...), b.Delta.Adds...),
+					Dels:    append(append([]string{}, a.Delta.Dels...), b.Delta.Dels...),
+					Updates: append(append([]*FluentUpdate{}, a.Delta.Updates...), b.Delta.Updates...),
+				},
+			})
+		}
+	}
+	return outs
+}
+
+func outcomes(f Formula, env *Env, b Bindings) ([]*Outcome, error) {
+	switch n := f.(type) {
+	case nil:
+		return certain(&Delta{}), nil
+	case *AndNode:
+		outs := certain(&Delta{})
+		for _, c := range n.MultiNode.Formula {
+			cs, err := outcomes(c, env, b)
+			if err != nil {
+				return nil, err
+			}
+			outs = combine(outs, cs)
+		}
+		return outs, nil
+	case *ForAllNode:
+		outs := certain(&Delta{})
+		_, err := env.Each(n.QuantNode.Variables, b, func(nb Bindings) (bool, error) {
+			cs, err := outcomes(n.QuantNode.UnaryNode.Formula, env, nb)
+			if err != nil {
+				return true, err
+			}
+			outs = combine(outs, cs)
+			return false, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return outs, nil
+	case *WhenNode:
+		ok, err := Evaluate(n.Condition, env, b)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return certain(&Delta{}), nil
+		}
+		return outcomes(n.UnaryNode.Formula, env, b)
+	case *ProbabilisticNode:
+		ps, err := n.Probabilities()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to apply %s: %v", n.ToString(""), err)
+		}
+		rest, err := n.Remainder()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to apply %s: %v", n.ToString(""), err)
+		}
+		outs := []*Outcome{}
+		for i, o := range n.Outcomes {
+			if ps[i] == 0 {
+				continue
+			}
+			cs, err := outcomes(o.Formula, env, b)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range cs {
+				c.Probability *= ps[i]
+			}
+			outs = append(outs, cs...)
+		}
+		if rest > 0 {
+			outs = append(outs, &Outcome{
+				Probability: rest,
+				Delta:       &Delta{},
+			})
+		}
+		return outs, nil
+	}
+	d := &Delta{}
+	if err := CollectEffects(f, env, b, d); err != nil {
+		return nil, err
+	}
+	return certain(d), nil
+}
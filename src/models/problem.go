@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"sort"
 )
 
 type Problem struct {
@@ -11,39 +12,89 @@ type Problem struct {
 	Objects           []*TypedEntry
 	InitialConditions []Formula
 	Goal              Formula
+	Metric            *Metric
+	// Htn is the initial task network of HDDL problems.
+	Htn *TaskNetwork
+}
+
+type Metric struct {
+	Node       *Node
+	Direction  *Name
+	Expression *NumericExpression
 }
 
 func (p *Problem) PrintProblem() {
+	if p == nil {
+		panic("Problem is nil, can't print")
+	}
+	fmt.Println(p.ToString())
+}
+
+// TimedInitials returns the timed initial literals and fluents of the
+// problem, ordered by time. Simultaneous ones keep their order.
+func (p *Problem) TimedInitials() []*TimedInitNode {
+	tils := []*TimedInitNode{}
+	for _, f := range p.InitialConditions {
+		if til, ok := f.(*TimedInitNode); ok {
+			tils = append(tils, til)
+		}
+	}
+	sort.SliceStable(tils, func(i, j int) bool {
+		return tils[i].Time.Float64() < tils[j].Time.Float64()
+	})
+	return tils
+}
+
+// ToString returns the problem in PDDL syntax.
+func (p *Problem) ToString() string {
 	var s string
 	s += fmt.Sprintf("(define (problem %s)\n%s(:domain %s)\n",
-		p.Name.Name, Indent(1), p.Domain.Name)
+		p.Name.Text(), Indent(1), p.Domain.Text())
 	s += toStringReqs(p.Requirements)
 	s += toStringConsts(":objects", p.Objects)
+	if p.Htn != nil {
+		s += toStringHtn(p.Htn)
+	}
 	s += fmt.Sprintf("%s(:init", Indent(1))
 	for _, f := range p.InitialConditions {
 		s += "\n"
 		s += f.ToString(Indent(2))
 	}
 	s += ")\n"
-	s += fmt.Sprintf("%s(:goal\n", Indent(1))
-	s += p.Goal.ToString(Indent(2))
-	s += ")\n)\n"
-	fmt.Println(s)
+	if p.Goal != nil {
+		s += fmt.Sprintf("%s(:goal\n", Indent(1))
+		s += p.Goal.ToString(Indent(2))
+		s += ")\n"
+	}
+	if p.Metric != nil {
+		s += fmt.Sprintf("%s(:metric %s %s)\n", Indent(1), p.Metric.Direction.Text(), p.Metric.Expression.ToString())
+	}
+	s += ")\n"
+	return s
 }
 
 func (p *Problem) ToJSONProblem() {
 	var s string
 	s += "{"
-	s += fmt.Sprintf("\"define\":{\"problem\":\"%s\",\"domain\":\"%s\"},", p.Name.Name, p.Domain.Name)
+	s += fmt.Sprintf("\"define\":{\"problem\":\"%s\",\"domain\":\"%s\"},", p.Name.Text(), p.Domain.Text())
 	s += toJSONReqs(p.Requirements)
 	s += toJSONConsts("objects", p.Objects)
+	if p.Htn != nil {
+		s += toJSONHtn(p.Htn)
+	}
 	s += "\"init\":{"
 	for _, f := range p.InitialConditions {
 		s += f.ToJSON("")
 	}
 	s += "}"
-	s += "\"goal\":{"
-	s += p.Goal.ToJSON("")
-	s += "}}"
+	if p.Goal != nil {
+		s += "\"goal\":{"
+		s += p.Goal.ToJSON("")
+		s += "}"
+	}
+	if p.Metric != nil {
+		s += fmt.Sprintf(",\"metric\":{\"%s\":%s}", p.Metric.Direction.Text(), p.Metric.Expression.ToJSON())
+	}
+	s += "}"
 	fmt.Println(s)
 }
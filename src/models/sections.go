@@ -6,6 +6,9 @@ type Predicate struct {
 	Parameters []*TypedEntry
 	PosEffect  bool
 	NegEffect  bool
+	// Private predicates are only known to the agent declaring them
+	// (MA-PDDL).
+	Private bool
 }
 
 type Action struct {
@@ -13,4 +16,7 @@ type Action struct {
 	Params       []*TypedEntry
 	Precondition Formula
 	Effect       Formula
+	// Agent is set when the first parameter is the :agent executing the
+	// action (MA-PDDL).
+	Agent bool
 }
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// State is a snapshot of the world: the set of ground atoms that
+// hold and the current value of every defined numeric and object
+// fluent.
+type State struct {
+	Facts   map[string]bool
+	Fluents map[string]float64
+	Objects map[string]string
+}
+
+func NewState() *State {
+	return &State{
+		Facts:   map[string]bool{},
+		Fluents: map[string]float64{},
+		Objects: map[string]string{},
+	}
+}
+
+// AtomKey returns the canonical key of a ground atom or ground
+// function head, e.g. "(on a b)".
+func AtomKey(name string, args []string) string {
+	if len(args) == 0 {
+		return "(" + name + ")"
+	}
+	return "(" + name + " " + strings.Join(args, " ") + ")"
+}
+
+func (s *State) Clone() *State {
+	c := NewState()
+	for k, v := range s.Facts {
+		c.Facts[k] = v
+	}
+	for k, v := range s.Fluents {
+		c.Fluents[k] = v
+	}
+	for k, v := range s.Objects {
+		c.Objects[k] = v
+	}
+	return c
+}
+
+func (s *State) Holds(name string, args []string) bool {
+	return s.Facts[AtomKey(name, args)]
+}
+
+func (s *State) Fluent(name string, args []string) (float64, bool) {
+	v, ok := s.Fluents[AtomKey(name, args)]
+	return v, ok
+}
+
+// ObjectFluent returns the object denoted by a ground object fluent.
+func (s *State) ObjectFluent(name string, args []string) (string, bool) {
+	v, ok := s.Objects[AtomKey(name, args)]
+	return v, ok
+}
+
+func (s *State) ToString() string {
+	facts := make([]string, 0, len(s.Facts))
+	for k, v := range s.Facts {
+		if v {
+			facts = append(facts, k)
+		}
+	}
+	sort.Strings(facts)
+	fluents := make([]string, 0, len(s.Fluents))
+	for k, v := range s.Fluents {
+		fluents = append(fluents, fmt.Sprintf("(= %s %s)", k, strconv.FormatFloat(v, 'g', -1, 64)))
+	}
+	for k, v := range s.Objects {
+		fluents = append(fluents, fmt.Sprintf("(= %s %s)", k, v))
+	}
+	sort.Strings(fluents)
+	return strings.Join(append(facts, fluents...), "\n")
+}
+
+// InitialState builds the state described by the :init section.
+func (p *Problem) InitialState() (*State, error) {
+	if p == nil {
+		return nil, fmt.Errorf("Failed to build initial state: problem is nil")
+	}
+	s := NewState()
+	// Object fluents initialized to function terms, such as
+	// (= (dest t1) (loc t2)), are set once the other ones are.
+	deferred := []*AssignNode{}
+	for _, f := range p.InitialConditions {
+		switch n := f.(type) {
+		case *TimedInitNode:
+			// Only holds once its time is reached
+			continue
+		case *LiteralNode:
+			if n.Negative {
+				continue
+			}
+			args := make([]string, len(n.Terms))
+			for i, t := range n.Terms {
+				args[i] = t.Name.Name
+			}
+			s.Facts[AtomKey(n.Predicate.Name, args)] = true
+		case *AssignNode:
+			if n.Term != nil && n.Term.Function != nil {
+				deferred = append(deferred, n)
+				continue
+			}
+			if n.Term != nil {
+				args := make([]string, len(n.AssignedTo.Terms))
+				for i, t := range n.AssignedTo.Terms {
+					args[i] = t.Name.Name
+				}
+				s.Objects[AtomKey(n.AssignedTo.Name.Name, args)] = n.Term.Name.Name
+				continue
+			}
+			if !n.IsNumber {
+				return nil, fmt.Errorf("Failed to build initial state: %s is not a numeric initialization", n.ToString(""))
+			}
+			v := n.Number.Float64()
+			args := make([]string, len(n.AssignedTo.Terms))
+			for i, t := range n.AssignedTo.Terms {
+				args[i] = t.Name.Name
+			}
+			s.Fluents[AtomKey(n.AssignedTo.Name.Name, args)] = v
+		default:
+			return nil, fmt.Errorf("Failed to build initial state: unsupported init element %s", f.ToString(""))
+		}
+	}
+	err := s.initObjects(deferred)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to build initial state: %v", err)
+	}
+	return s, nil
+}
+
+// initObjects sets the object fluents initialized to function terms,
+// in as many passes as the terms depend on each other.
+func (s *State) initObjects(ns []*AssignNode) error {
+	for len(ns) > 0 {
+		left := []*AssignNode{}
+		for _, n := range ns {
+			fi := n.Term.Function
+			fargs := make([]string, len(fi.Terms))
+			for i, t := range fi.Terms {
+				fargs[i] = t.Name.Name
+			}
+			v, ok := s.ObjectFluent(fi.Name.Name, fargs)
+			if !ok {
+				left = append(left, n)
+				continue
+			}
+			args := make([]string, len(n.AssignedTo.Terms))
+			for i, t := range n.AssignedTo.Terms {
+				args[i] = t.Name.Name
+			}
+			s.Objects[AtomKey(n.AssignedTo.Name.Name, args)] = v
+		}
+		if len(left) == len(ns) {
+			return fmt.Errorf("value of %s is undefined", ns[0].Term.Function.ToString())
+		}
+		ns = left
+	}
+	return nil
+}
+
+// FluentUpdate changes a numeric fluent by Value, or assigns Object
+// to an object fluent.
+type FluentUpdate struct {
+	Operation string
+	Key       string
+	Value     float64
+	IsObject  bool
+	Object    string
+}
+
+// Delta is the ground change an action makes to a state. Every
+// condition and value is evaluated in the state before the action.
+type Delta struct {
+	Adds    []string
+	Dels    []string
+	Updates []*FluentUpdate
+}
+
+// Apply returns the successor state: deletes are applied before
+// adds, so an atom both deleted and added holds afterwards.
+func (d *Delta) Apply(s *State) (*State, error) {
+	next := s.Clone()
+	for _, k := range d.Dels {
+		delete(next.Facts, k)
+	}
+	for _, k := range d.Adds {
+		next.Facts[k] = true
+	}
+	for _, u := range d.Updates {
+		if u.IsObject {
+			next.Objects[u.Key] = u.Object
+			continue
+		}
+		if u.Operation == "assign" || u.Operation == "=" {
+			next.Fluents[u.Key] = u.Value
+			continue
+		}
+		cur, ok := next.Fluents[u.Key]
+		if !ok {
+			return nil, fmt.Errorf("Failed to %s %s: fluent is undefined", u.Operation, u.Key)
+		}
+		switch u.Operation {
+		case "increase":
+			next.Fluents[u.Key] = cur + u.Value
+		case "decrease":
+			next.Fluents[u.Key] = cur - u.Value
+		case "scale-up":
+			next.Fluents[u.Key] = cur * u.Value
+		case "scale-down":
+			if u.Value == 0 {
+				return nil, fmt.Errorf("Failed to scale-down %s: division by zero", u.Key)
+			}
+			next.Fluents[u.Key] = cur / u.Value
+		default:
+			return nil, fmt.Errorf("Failed to update %s: unknown operation %s", u.Key, u.Operation)
+		}
+	}
+	return next, nil
+}
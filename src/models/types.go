@@ -0,0 +1,255 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TypeHierarchy links the types of a domain to their supertypes and
+// to the constants and objects they contain. Every type descends from
+// the object root, declared or not.
+type TypeHierarchy struct {
+	Root  *Type
+	Types map[string]*Type
+	// subtypes maps every type to its direct subtypes, in declaration order.
+	subtypes map[string][]*Type
+}
+
+func newTypeError(loc *Location, format string, args ...interface{}) *PddlError {
+	return &PddlError{
+		Location: loc,
+		Error:    fmt.Errorf("Failed to build type hierarchy: "+format, args...),
+	}
+}
+
+// NewTypeHierarchy builds the hierarchy of the types section of the
+// domain. The hierarchy has its own types, so the domain is left
+// untouched and may be shared by concurrent calls. Undeclared
+// supertypes and cycles are reported with their location. The
+// problem may be nil, otherwise its objects are added to the domains
+// of their types.
+func NewTypeHierarchy(d *Domain, pb *Problem) (*TypeHierarchy, *PddlError) {
+	if d == nil {
+		return nil, newTypeError(nil, "domain is nil")
+	}
+	h := &TypeHierarchy{
+		Types:    map[string]*Type{},
+		subtypes: map[string][]*Type{},
+	}
+	// A type may be declared more than once, with different supertypes.
+	declared := []*Type{}
+	for _, t := range d.Types {
+		n := t.TypedEntry.Name.Name
+		if _, ok := h.Types[n]; !ok {
+			node := &Type{
+				TypedEntry: t.TypedEntry,
+			}
+			h.Types[n] = node
+			declared = append(declared, node)
+		}
+	}
+	h.Root = h.Types["object"]
+	if h.Root == nil {
+		h.Root = &Type{
+			TypedEntry: &TypedEntry{
+				Name: &Name{
+					Name:     "object",
+					Location: &Location{},
+				},
+			},
+		}
+		h.Types["object"] = h.Root
+	}
+
+	for _, t := range d.Types {
+		decl := h.Types[t.TypedEntry.Name.Name]
+		for _, tn := range t.TypedEntry.Types {
+			st, ok := h.Types[tn.Name.Name]
+			if !ok {
+				return nil, newTypeError(tn.Name.Location, "supertype %s of %s isn't declared", tn.Name.Name, t.TypedEntry.Name.Name)
+			}
+			if st == decl {
+				return nil, newTypeError(tn.Name.Location, "type %s is its own supertype", tn.Name.Name)
+			}
+			decl.Predecessors = append(decl.Predecessors, st)
+			h.subtypes[tn.Name.Name] = append(h.subtypes[tn.Name.Name], decl)
+		}
+	}
+	// Types without supertype descend from object.
+	for _, t := range declared {
+		if t != h.Root && len(t.Predecessors) == 0 {
+			t.Predecessors = []*Type{h.Root}
+			h.subtypes["object"] = append(h.subtypes["object"], t)
+		}
+	}
+	if len(h.Root.Predecessors) > 0 {
+		return nil, newTypeError(h.Root.TypedEntry.Name.Location, "type object can't have a supertype")
+	}
+	if err := h.checkCycles(declared); err != nil {
+		return nil, err
+	}
+
+	if err := h.addObjects(d.Constants); err != nil {
+		return nil, err
+	}
+	if pb != nil {
+		if err := h.addObjects(pb.Objects); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// checkCycles reports the first type that is its own ancestor.
+func (h *TypeHierarchy) checkCycles(types []*Type) *PddlError {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[*Type]int{}
+	var visit func(t *Type, path []string) *PddlError
+	visit = func(t *Type, path []string) *PddlError {
+		path = append(path, t.TypedEntry.Name.Name)
+		switch state[t] {
+		case visiting:
+			return newTypeError(t.TypedEntry.Name.Location, "cyclic type declaration %s", strings.Join(path, " - "))
+		case visited:
+			return nil
+		}
+		state[t] = visiting
+		for _, p := range t.Predecessors {
+			if err := visit(p, path); err != nil {
+				return err
+			}
+		}
+		state[t] = visited
+		return nil
+	}
+	for _, t := range types {
+		if err := visit(t, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addObjects adds the entries to the domain of their types. Untyped
+// entries are objects.
+func (h *TypeHierarchy) addObjects(entries []*TypedEntry) *PddlError {
+	for _, e := range entries {
+		if len(e.Types) == 0 {
+			h.Root.Domain = append(h.Root.Domain, e)
+			continue
+		}
+		for _, tn := range e.Types {
+			t, ok := h.Types[tn.Name.Name]
+			if !ok {
+				return newTypeError(tn.Name.Location, "type %s of %s isn't declared", tn.Name.Name, e.Name.Name)
+			}
+			t.Domain = append(t.Domain, e)
+		}
+	}
+	return nil
+}
+
+// Supertypes returns the type and all its ancestors.
+func (h *TypeHierarchy) Supertypes(t string) []string {
+	tp, ok := h.Types[t]
+	if !ok {
+		return nil
+	}
+	seen := map[*Type]bool{}
+	out := []string{}
+	queue := []*Type{tp}
+	for len(queue) > 0 {
+		tp, queue = queue[0], queue[1:]
+		if seen[tp] {
+			continue
+		}
+		seen[tp] = true
+		out = append(out, tp.TypedEntry.Name.Name)
+		queue = append(queue, tp.Predecessors...)
+	}
+	return out
+}
+
+// Subtypes returns the type and all its descendants.
+func (h *TypeHierarchy) Subtypes(t string) []string {
+	if _, ok := h.Types[t]; !ok {
+		return nil
+	}
+	seen := map[string]bool{}
+	out := []string{}
+	queue := []string{t}
+	for len(queue) > 0 {
+		t, queue = queue[0], queue[1:]
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+		for _, st := range h.subtypes[t] {
+			queue = append(queue, st.TypedEntry.Name.Name)
+		}
+	}
+	return out
+}
+
+// IsSubtype tells whether a is b or one of its descendants.
+func (h *TypeHierarchy) IsSubtype(a, b string) bool {
+	for _, t := range h.Supertypes(a) {
+		if t == b {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectsOfType returns the constants and objects of the type and of
+// its subtypes, each once, sorted by name.
+func (h *TypeHierarchy) ObjectsOfType(t string) []*TypedEntry {
+	seen := map[string]bool{}
+	out := []*TypedEntry{}
+	for _, st := range h.Subtypes(t) {
+		for _, e := range h.Types[st].Domain {
+			if !seen[e.Name.Name] {
+				seen[e.Name.Name] = true
+				out = append(out, e)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name.Name < out[j].Name.Name
+	})
+	return out
+}
+
+// ToString prints the hierarchy as a tree rooted at object, with the
+// objects of every type. Types with several supertypes appear under
+// each of them.
+func (h *TypeHierarchy) ToString() string {
+	var sb strings.Builder
+	var write func(t *Type, depth int)
+	write = func(t *Type, depth int) {
+		sb.WriteString(Indent(depth) + t.TypedEntry.Name.Name)
+		if len(t.Domain) > 0 {
+			names := []string{}
+			for _, e := range t.Domain {
+				names = append(names, e.Name.Name)
+			}
+			sb.WriteString(": " + strings.Join(names, " "))
+		}
+		sb.WriteString("\n")
+		for _, st := range h.subtypes[t.TypedEntry.Name.Name] {
+			write(st, depth+1)
+		}
+	}
+	write(h.Root, 0)
+	return sb.String()
+}
+
+func (h *TypeHierarchy) PrintHierarchy() {
+	fmt.Print(h.ToString())
+}
@@ -0,0 +1,126 @@
+package models_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+	"github.com/guilyx/go-pddl/src/parser"
+)
+
+const typesProblem = `(define (problem fleet) (:domain fleet)
+  (:objects t1 - truck b1 - boat amphi - (either truck boat) d1 - driver))
+`
+
+// typesDomain returns a domain declaring the types.
+func typesDomain(types string) string {
+	return `(define (domain fleet)
+  (:requirements :strips :typing)
+  (:types ` + types + `)
+  (:predicates (at ?v - vehicle)))
+`
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	d, pb, err := parser.ParseTexts(&config.Config{}, typesDomain("truck boat - vehicle vehicle driver"), typesProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, perr := models.NewTypeHierarchy(d, pb)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"truck", "vehicle", true},
+		{"truck", "object", true},
+		{"vehicle", "vehicle", true},
+		{"vehicle", "truck", false},
+		{"driver", "vehicle", false},
+		{"ship", "object", false},
+	}
+	for _, test := range tests {
+		if got := h.IsSubtype(test.a, test.b); got != test.want {
+			t.Errorf("IsSubtype(%s, %s) = %t, want %t", test.a, test.b, got, test.want)
+		}
+	}
+	objects := func(tp string) string {
+		names := []string{}
+		for _, e := range h.ObjectsOfType(tp) {
+			names = append(names, e.Name.Name)
+		}
+		return strings.Join(names, " ")
+	}
+	want := map[string]string{
+		"vehicle": "amphi b1 t1",
+		"truck":   "amphi t1",
+		"driver":  "d1",
+		"object":  "amphi b1 d1 t1",
+	}
+	for tp, objs := range want {
+		if got := objects(tp); got != objs {
+			t.Errorf("objects of %s: got %q, want %q", tp, got, objs)
+		}
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(u["vehicle"], " "); got != want["vehicle"] {
+		t.Errorf("universe of vehicle: got %q, want %q", got, want["vehicle"])
+	}
+}
+
+func TestTypeHierarchyErrors(t *testing.T) {
+	tests := []struct {
+		types string
+		want  string
+	}{
+		{"a - b b - c c - a", "cyclic type declaration"},
+		{"a - a", "type a is its own supertype"},
+		{"truck - vehicle", "supertype vehicle of truck isn't declared"},
+		{"object - thing thing", "type object can't have a supertype"},
+	}
+	for _, test := range tests {
+		_, _, err := parser.ParseTexts(&config.Config{}, typesDomain(test.types), `(define (problem fleet) (:domain fleet))`)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: got error %v, want %q", test.types, err, test.want)
+		}
+	}
+}
+
+func TestConcurrentUniverses(t *testing.T) {
+	d, pb, err := parser.ParseTexts(&config.Config{}, typesDomain("truck boat - vehicle vehicle driver"), typesProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := d.ToString()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := models.NewUniverse(d, pb)
+			if err == nil && len(u["vehicle"]) != 3 {
+				err = fmt.Errorf("got vehicles %v", u["vehicle"])
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if d.ToString() != types || d.Types[0].Predecessors != nil {
+		t.Errorf("building the universes modified the domain")
+	}
+}
@@ -0,0 +1,185 @@
+package models
+
+// Composite is implemented by nodes with subformulas. Walk and
+// Rewrite only rely on it, so a new node type is traversed as soon
+// as it implements it.
+type Composite interface {
+	Children() []Formula
+	// SetChildren replaces the subformulas, in the order
+	// returned by Children.
+	SetChildren([]Formula)
+}
+
+// Visitor is called by Walk for every node. If the returned visitor
+// is not nil, Walk visits the children of the node with it, followed
+// by a call of Visit(nil).
+type Visitor interface {
+	Visit(f Formula) Visitor
+}
+
+// Children returns the direct subformulas of a node.
+func Children(f Formula) []Formula {
+	if c, ok := f.(Composite); ok {
+		return c.Children()
+	}
+	return nil
+}
+
+// Walk traverses the formula in depth-first order, like ast.Walk.
+func Walk(v Visitor, f Formula) {
+	if f == nil {
+		return
+	}
+	if v = v.Visit(f); v == nil {
+		return
+	}
+	for _, c := range Children(f) {
+		Walk(v, c)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Formula) bool
+
+func (fn inspector) Visit(f Formula) Visitor {
+	if fn(f) {
+		return fn
+	}
+	return nil
+}
+
+// Inspect traverses the formula in depth-first order, calling fn for
+// every node and skipping the children of nodes for which fn returns
+// false. Like ast.Inspect, fn is called with nil after the children.
+func Inspect(f Formula, fn func(Formula) bool) {
+	Walk(inspector(fn), f)
+}
+
+// Rewrite replaces the nodes of the formula bottom-up: fn is called
+// with every node once its children were rewritten, and its result
+// replaces the node in its parent. Composite nodes are updated in
+// place; the rewritten root is returned.
+func Rewrite(f Formula, fn func(Formula) Formula) Formula {
+	if f == nil {
+		return nil
+	}
+	if c, ok := f.(Composite); ok {
+		cs := c.Children()
+		for i := range cs {
+			cs[i] = Rewrite(cs[i], fn)
+		}
+		c.SetChildren(cs)
+	}
+	return fn(f)
+}
+
+// WalkDomain walks the precondition and effect of every action,
+// process and event, and the precondition of every method.
+func WalkDomain(v Visitor, d *Domain) {
+	for _, a := range d.Structures() {
+		Walk(v, a.Precondition)
+		Walk(v, a.Effect)
+	}
+	for _, m := range d.Methods {
+		Walk(v, m.Precondition)
+	}
+}
+
+// WalkProblem walks the init elements and the goal.
+func WalkProblem(v Visitor, pb *Problem) {
+	for _, f := range pb.InitialConditions {
+		Walk(v, f)
+	}
+	Walk(v, pb.Goal)
+}
+
+func RewriteDomain(d *Domain, fn func(Formula) Formula) {
+	for _, a := range d.Structures() {
+		a.Precondition = Rewrite(a.Precondition, fn)
+		a.Effect = Rewrite(a.Effect, fn)
+	}
+	for _, m := range d.Methods {
+		m.Precondition = Rewrite(m.Precondition, fn)
+	}
+}
+
+func RewriteProblem(pb *Problem, fn func(Formula) Formula) {
+	for i, f := range pb.InitialConditions {
+		pb.InitialConditions[i] = Rewrite(f, fn)
+	}
+	pb.Goal = Rewrite(pb.Goal, fn)
+}
+
+func (n *AndNode) Children() []Formula {
+	return append([]Formula{}, n.MultiNode.Formula...)
+}
+
+func (n *AndNode) SetChildren(fs []Formula) {
+	n.MultiNode.Formula = fs
+}
+
+func (n *OrNode) Children() []Formula {
+	return append([]Formula{}, n.MultiNode.Formula...)
+}
+
+func (n *OrNode) SetChildren(fs []Formula) {
+	n.MultiNode.Formula = fs
+}
+
+func (n *OneOfNode) Children() []Formula {
+	return append([]Formula{}, n.MultiNode.Formula...)
+}
+
+func (n *OneOfNode) SetChildren(fs []Formula) {
+	n.MultiNode.Formula = fs
+}
+
+func (n *NotNode) Children() []Formula {
+	return []Formula{n.UnaryNode.Formula}
+}
+
+func (n *NotNode) SetChildren(fs []Formula) {
+	n.UnaryNode.Formula = fs[0]
+}
+
+func (n *ImplyNode) Children() []Formula {
+	return []Formula{n.BinaryNode.Left, n.BinaryNode.Right}
+}
+
+func (n *ImplyNode) SetChildren(fs []Formula) {
+	n.BinaryNode.Left = fs[0]
+	n.BinaryNode.Right = fs[1]
+}
+
+func (n *ForAllNode) Children() []Formula {
+	return []Formula{n.QuantNode.UnaryNode.Formula}
+}
+
+func (n *ForAllNode) SetChildren(fs []Formula) {
+	n.QuantNode.UnaryNode.Formula = fs[0]
+}
+
+func (n *ExistsNode) Children() []Formula {
+	return []Formula{n.QuantNode.UnaryNode.Formula}
+}
+
+func (n *ExistsNode) SetChildren(fs []Formula) {
+	n.QuantNode.UnaryNode.Formula = fs[0]
+}
+
+func (n *TimedInitNode) Children() []Formula {
+	return []Formula{n.UnaryNode.Formula}
+}
+
+func (n *TimedInitNode) SetChildren(fs []Formula) {
+	n.UnaryNode.Formula = fs[0]
+}
+
+func (n *WhenNode) Children() []Formula {
+	return []Formula{n.Condition, n.UnaryNode.Formula}
+}
+
+func (n *WhenNode) SetChildren(fs []Formula) {
+	n.Condition = fs[0]
+	n.UnaryNode.Formula = fs[1]
+}
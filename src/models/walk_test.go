@@ -0,0 +1,139 @@
+package models_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+	"github.com/guilyx/go-pddl/src/parser"
+)
+
+const walkDomain = `(define (domain walk)
+  (:requirements :adl)
+  (:predicates (p) (q) (r ?x) (s))
+  (:action a
+    :parameters ()
+    :precondition (and (p) (not (and (q) (s))) (forall (?x) (r ?x)))
+    :effect (and (s) (when (p) (not (s))))))
+`
+
+const walkProblem = `(define (problem walk) (:domain walk)
+  (:objects o)
+  (:init (p) (q) (r o))
+  (:goal (or (s) (not (p)))))
+`
+
+// label names a node for the traces: its predicate for literals, its
+// type otherwise, and ")" for the call with nil.
+func label(f models.Formula) string {
+	switch n := f.(type) {
+	case nil:
+		return ")"
+	case *models.LiteralNode:
+		if n.Negative {
+			return "-" + n.Predicate.Name
+		}
+		return n.Predicate.Name
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(fmt.Sprintf("%T", f), "*models."), "Node")
+}
+
+type tracer struct {
+	trace *[]string
+}
+
+func (v tracer) Visit(f models.Formula) models.Visitor {
+	*v.trace = append(*v.trace, label(f))
+	return v
+}
+
+func parseWalk(t *testing.T) (*models.Domain, *models.Problem) {
+	d, pb, err := parser.ParseTexts(&config.Config{}, walkDomain, walkProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, pb
+}
+
+func TestWalk(t *testing.T) {
+	d, pb := parseWalk(t)
+	trace := []string{}
+	models.Walk(tracer{&trace}, d.Actions[0].Precondition)
+	want := "And p ) Not And q ) s ) ) ) ForAll r ) ) )"
+	if got := strings.Join(trace, " "); got != want {
+		t.Errorf("got trace %s, want %s", got, want)
+	}
+	trace = nil
+	models.WalkProblem(tracer{&trace}, pb)
+	want = "p ) q ) r ) Or s ) -p ) )"
+	if got := strings.Join(trace, " "); got != want {
+		t.Errorf("got trace %s, want %s", got, want)
+	}
+	trace = nil
+	models.Walk(tracer{&trace}, nil)
+	if len(trace) != 0 {
+		t.Errorf("got trace %v for nil", trace)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	d, _ := parseWalk(t)
+	trace := []string{}
+	models.Inspect(d.Actions[0].Effect, func(f models.Formula) bool {
+		trace = append(trace, label(f))
+		// The children of when are skipped, and so is its nil call.
+		_, ok := f.(*models.WhenNode)
+		return !ok
+	})
+	want := "And s ) When )"
+	if got := strings.Join(trace, " "); got != want {
+		t.Errorf("got trace %s, want %s", got, want)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	d, pb := parseWalk(t)
+	// Rename p in place, replace q and s by new literals.
+	fn := func(f models.Formula) models.Formula {
+		n, ok := f.(*models.LiteralNode)
+		if !ok {
+			return f
+		}
+		switch n.Predicate.Name {
+		case "p":
+			n.Predicate.Name = "p2"
+		case "q", "s":
+			return &models.LiteralNode{
+				Node: n.Node,
+				Predicate: &models.Name{
+					Name:     n.Predicate.Name + "2",
+					Location: n.Predicate.Location,
+				},
+				Negative: n.Negative,
+				IsEffect: n.IsEffect,
+			}
+		}
+		return f
+	}
+	pre := d.Actions[0].Precondition
+	models.RewriteDomain(d, fn)
+	models.RewriteProblem(pb, fn)
+	if d.Actions[0].Precondition != pre {
+		t.Errorf("the root of the precondition was replaced")
+	}
+	trace := []string{}
+	models.Walk(tracer{&trace}, d.Actions[0].Precondition)
+	models.Walk(tracer{&trace}, d.Actions[0].Effect)
+	models.WalkProblem(tracer{&trace}, pb)
+	want := "And p2 ) Not And q2 ) s2 ) ) ) ForAll r ) ) ) " +
+		"And s2 ) When p2 ) -s2 ) ) ) " +
+		"p2 ) q2 ) r ) Or s2 ) -p2 ) )"
+	if got := strings.Join(trace, " "); got != want {
+		t.Errorf("got trace %s, want %s", got, want)
+	}
+	if models.Rewrite(nil, fn) != nil {
+		t.Errorf("rewriting nil: got a formula")
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// Task names the domain and problem files of a planning task.
+type Task struct {
+	Domain  string
+	Problem string
+}
+
+// Parsed is a task with its parsed domain and problem, or the error
+// that prevented parsing it.
+type Parsed struct {
+	Task    Task
+	Domain  *models.Domain
+	Problem *models.Problem
+	Error   error
+}
+
+// ParseBatch parses the tasks concurrently, with at most workers
+// goroutines, or one per CPU when workers isn't positive. The results
+// are in the order of the tasks.
+func ParseBatch(conf *config.Config, tasks []Task, workers int) []*Parsed {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	out := make([]*Parsed, len(tasks))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				out[i] = parseTask(conf, tasks[i])
+			}
+		}()
+	}
+	for i := range tasks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return out
+}
+
+// parseTask parses a task, turning a panic of the parser into an error
+// so that it doesn't bring the other tasks down.
+func parseTask(conf *config.Config, t Task) (parsed *Parsed) {
+	parsed = &Parsed{
+		Task: t,
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			parsed.Domain, parsed.Problem = nil, nil
+			parsed.Error = fmt.Errorf("Failed to parse %s: %v", t.Problem, r)
+		}
+	}()
+	parsed.Domain, parsed.Problem, parsed.Error = ParseFiles(conf, t.Domain, t.Problem)
+	return parsed
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/lexer"
+)
+
+const testDomain = `(define (domain blocks)
+  (:requirements :strips :typing)
+  (:types block)
+  (:predicates (on ?x - block ?y - block) (ontable ?x - block)
+               (clear ?x - block) (handempty) (holding ?x - block))
+  (:action pick-up
+    :parameters (?x - block)
+    :precondition (and (clear ?x) (ontable ?x) (handempty))
+    :effect (and (not (ontable ?x)) (not (clear ?x)) (not (handempty)) (holding ?x)))
+  (:action stack
+    :parameters (?x - block ?y - block)
+    :precondition (and (holding ?x) (clear ?y))
+    :effect (and (not (holding ?x)) (not (clear ?y)) (clear ?x) (handempty) (on ?x ?y))))
+`
+
+// testProblem returns a problem with n blocks on the table.
+func testProblem(n int) string {
+	var objs, init []string
+	for i := 0; i < n; i++ {
+		b := fmt.Sprintf("b%d", i)
+		objs = append(objs, b)
+		init = append(init, fmt.Sprintf("(ontable %s) (clear %s)", b, b))
+	}
+	return fmt.Sprintf(`(define (problem p%d) (:domain blocks)
+  (:objects %s - block)
+  (:init (handempty) %s)
+  (:goal (and (on b0 b1))))
+`, n, strings.Join(objs, " "), strings.Join(init, " "))
+}
+
+func writeTasks(t *testing.T, n int) []Task {
+	dir, err := ioutil.TempDir("", "batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	domain := filepath.Join(dir, "domain.pddl")
+	err = ioutil.WriteFile(domain, []byte(testDomain), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := []Task{}
+	for i := 0; i < n; i++ {
+		problem := filepath.Join(dir, fmt.Sprintf("p%d.pddl", i))
+		err = ioutil.WriteFile(problem, []byte(testProblem(i+2)), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, Task{
+			Domain:  domain,
+			Problem: problem,
+		})
+	}
+	return tasks
+}
+
+func TestParseBatch(t *testing.T) {
+	conf := &config.Config{
+		Identifiers: config.IdentifiersPreserve,
+	}
+	tasks := writeTasks(t, 32)
+	tasks = append(tasks, Task{
+		Domain:  tasks[0].Domain,
+		Problem: tasks[0].Problem + ".missing",
+	})
+	parsed := ParseBatch(conf, tasks, 8)
+	if len(parsed) != len(tasks) {
+		t.Fatalf("got %d results for %d tasks", len(parsed), len(tasks))
+	}
+	for i, res := range parsed[:len(parsed)-1] {
+		if res.Error != nil {
+			t.Fatalf("task %d: %v", i, res.Error)
+		}
+		d, pb, err := ParseFiles(conf, tasks[i].Domain, tasks[i].Problem)
+		if err != nil {
+			t.Fatalf("task %d: %v", i, err)
+		}
+		if res.Task != tasks[i] {
+			t.Errorf("task %d: result of %v", i, res.Task)
+		}
+		if res.Domain.ToString() != d.ToString() || res.Problem.ToString() != pb.ToString() {
+			t.Errorf("task %d: parsed differently in a batch", i)
+		}
+		if len(res.Problem.Objects) != i+2 {
+			t.Errorf("task %d: got %d objects, want %d", i, len(res.Problem.Objects), i+2)
+		}
+	}
+	if parsed[len(parsed)-1].Error == nil {
+		t.Errorf("missing problem file: got no error")
+	}
+}
+
+func TestConcurrentLexers(t *testing.T) {
+	text := testProblem(50)
+	want := 0
+	lx, err := lexer.NewLexer("problem", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tk, _ := lx.ScanToken(); tk.Type != lexer.TOKEN_EOF; tk, _ = lx.ScanToken() {
+		want++
+	}
+	var wg sync.WaitGroup
+	counts := make([]int, 16)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var lx *lexer.Lexer
+			var err error
+			if i%2 == 0 {
+				lx, err = lexer.NewLexer("problem", text)
+			} else {
+				lx, err = lexer.NewReaderLexer("problem", strings.NewReader(text))
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for tk, err := lx.ScanToken(); err == nil && tk.Type != lexer.TOKEN_EOF; tk, err = lx.ScanToken() {
+				if tk.Type == lexer.TOKEN_ERROR {
+					t.Errorf("lexer %d: %s", i, tk.Text)
+					return
+				}
+				if _, err := tk.Type.ToString(); err != nil {
+					t.Errorf("lexer %d: %v", i, err)
+				}
+				counts[i]++
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, n := range counts {
+		if n != want {
+			t.Errorf("lexer %d: got %d tokens, want %d", i, n, want)
+		}
+	}
+}
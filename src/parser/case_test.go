@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+const caseDomain = `(DEFINE (DOMAIN Blocks)
+  (:REQUIREMENTS :STRIPS :Typing :Negative-Preconditions)
+  (:TYPES Block)
+  (:PREDICATES (On ?x - Block ?y - Block) (Clear ?x - Block))
+  (:Action Stack
+    :PARAMETERS (?x - Block ?y - Block)
+    :PRECONDITION (AND (Clear ?x) (Clear ?y) (NOT (On ?x ?y)))
+    :EFFECT (AND (On ?x ?y) (NOT (Clear ?y)))))
+`
+
+// caseProblem returns a problem spelling the type of its objects as
+// given.
+func caseProblem(block string) string {
+	return `(DEFINE (PROBLEM Two) (:DOMAIN Blocks)
+  (:OBJECTS A B - ` + block + `)
+  (:INIT (Clear A) (Clear B))
+  (:GOAL (AND (On A B))))
+`
+}
+
+func TestUpperCaseKeywords(t *testing.T) {
+	tests := []struct {
+		policy string
+		pred   string
+		action string
+	}{
+		{config.IdentifiersPreserve, "On", "Stack"},
+		{config.IdentifiersLower, "on", "stack"},
+	}
+	for _, test := range tests {
+		conf := &config.Config{
+			Identifiers: test.policy,
+		}
+		d, pb, err := ParseTexts(conf, caseDomain, caseProblem("Block"))
+		if err != nil {
+			t.Errorf("%s: %v", test.policy, err)
+			continue
+		}
+		if d.Predicates[0].Name.Name != test.pred || d.Actions[0].Name.Name != test.action {
+			t.Errorf("%s: got predicate %s and action %s", test.policy, d.Predicates[0].Name.Name, d.Actions[0].Name.Name)
+		}
+		if got := d.Requirements[1].Name; got != ":typing" {
+			t.Errorf("%s: got requirement %s, want :typing", test.policy, got)
+		}
+		and, ok := d.Actions[0].Precondition.(*models.AndNode)
+		if !ok || len(and.MultiNode.Formula) != 3 {
+			t.Errorf("%s: got precondition %s", test.policy, d.Actions[0].Precondition.ToString(""))
+		}
+		if _, ok := pb.Goal.(*models.AndNode); !ok {
+			t.Errorf("%s: got goal %s", test.policy, pb.Goal.ToString(""))
+		}
+		// The spelling of the files is printed.
+		for _, want := range []string{"(On ?x ?y)", "(:action Stack"} {
+			if !strings.Contains(d.ToString(), want) {
+				t.Errorf("%s: %s isn't printed:\n%s", test.policy, want, d.ToString())
+			}
+		}
+	}
+	// Only the lower-case policy matches differently spelled names.
+	for policy, ok := range map[string]bool{config.IdentifiersLower: true, config.IdentifiersPreserve: false} {
+		d, pb, err := ParseTexts(&config.Config{Identifiers: policy}, caseDomain, caseProblem("BLOCK"))
+		if err != nil {
+			t.Fatalf("%s: %v", policy, err)
+		}
+		if _, perr := models.NewTypeHierarchy(d, pb); (perr == nil) != ok {
+			t.Errorf("%s: got error %v for the objects of type BLOCK", policy, perr)
+		}
+	}
+}
@@ -3,28 +3,73 @@ package parser
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/cst"
 	"github.com/guilyx/go-pddl/src/lexer"
 	"github.com/guilyx/go-pddl/src/models"
 )
 
+// ParserToolbox parses in two phases: the text is first read into its
+// s-expression tree, and the grammar then walks the tokens of the tree,
+// with unbounded lookahead.
 type ParserToolbox struct {
 	Lexer         *lexer.Lexer
-	Peeks         []*lexer.ScannedToken
-	nPeeks        int
+	Tree          *cst.Tree
 	Configuration *config.Config
+	// Skipped are the keywords of the unknown sections that were
+	// skipped.
+	Skipped []*models.Name
+
+	tokens []*lexer.ScannedToken
+	// ends maps the index of every opening parenthesis to the index of
+	// its closing one.
+	ends map[int]int
+
+	// Diagnostics: the number of consumed tokens, the texts tried by
+	// Accepts at each token position, and the grammar contexts.
+	consumed int
+	tried    map[int][]string
+	contexts []string
+	// lists are the locations of the lists being parsed.
+	lists []*models.Location
 }
 
 func NewParserToolbox(config *config.Config, lx *lexer.Lexer) (*ParserToolbox, error) {
 	if lx == nil || config == nil {
 		return nil, fmt.Errorf("Failed to create new parser: config or lexer is nil")
 	}
+	tree, err := cst.ReadLexer(lx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new parser: %v", err)
+	}
+	return newTreeToolbox(config, lx, tree), nil
+}
+
+// newTreeToolbox returns a parser of the tokens of a tree read by the
+// lexer.
+func newTreeToolbox(config *config.Config, lx *lexer.Lexer, tree *cst.Tree) *ParserToolbox {
+	tks := tree.Tokens()
+	ends := map[int]int{}
+	opened := []int{}
+	for i, tk := range tks {
+		switch tk.Type {
+		case lexer.TOKEN_OPEN:
+			opened = append(opened, i)
+		case lexer.TOKEN_CLOSE:
+			ends[opened[len(opened)-1]] = i
+			opened = opened[:len(opened)-1]
+		}
+	}
 	return &ParserToolbox{
 		Configuration: config,
 		Lexer:         lx,
-		Peeks:         make([]*lexer.ScannedToken, config.MaxPeek),
-	}, nil
+		Tree:          tree,
+		tokens:        tks,
+		ends:          ends,
+		tried:         map[int][]string{},
+	}
 }
 
 func (p *ParserToolbox) NewPddlError(format string, args ...interface{}) *models.PddlError {
@@ -41,31 +86,66 @@ func (p *ParserToolbox) NewPddlError(format string, args ...interface{}) *models
 	}
 }
 
+// newTokenError returns an error located at the token.
+func (p *ParserToolbox) newTokenError(tk *lexer.ScannedToken, msg string) *models.PddlError {
+	return &models.PddlError{
+		Location: p.locateToken(tk),
+		Error:    fmt.Errorf("%s", msg),
+	}
+}
+
 func (p *ParserToolbox) Next() (*lexer.ScannedToken, error) {
-	if p == nil || p.Lexer == nil || p.Peeks == nil {
+	if p == nil || p.Lexer == nil || p.tokens == nil {
 		return nil, fmt.Errorf("Failed to get the next lexical token")
 	}
-	if p.nPeeks == 0 {
-		tk, err := p.Lexer.ScanToken()
-		if err != nil {
-			return nil, fmt.Errorf("Failed to get the next lexical token from the parser: %v", err)
-		}
-		return tk, nil
+	t := p.token(0)
+	if t.Type == lexer.TOKEN_EOF {
+		return t, nil
+	}
+	// Forget the alternatives of the previous token.
+	delete(p.tried, p.consumed-1)
+	p.consumed++
+	switch {
+	case t.Type == lexer.TOKEN_OPEN:
+		p.lists = append(p.lists, p.locateToken(t))
+	case t.Type == lexer.TOKEN_CLOSE && len(p.lists) > 0:
+		p.lists = p.lists[:len(p.lists)-1]
 	}
-	t := p.Peeks[0]
-	copy(p.Peeks[:], p.Peeks[1:])
-	p.nPeeks -= 1
 	return t, nil
 }
 
+// locateList returns the location of the innermost list being parsed,
+// for the nodes built once their opening tokens are consumed.
+func (p *ParserToolbox) locateList() (*models.Location, error) {
+	if len(p.lists) == 0 {
+		return p.Locate()
+	}
+	return p.lists[len(p.lists)-1], nil
+}
+
+// token returns the nth token after the next one, or the EOF token
+// past the end.
+func (p *ParserToolbox) token(n int) *lexer.ScannedToken {
+	if i := p.consumed + n; i < len(p.tokens) {
+		return p.tokens[i]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+// Locate returns the location of the next token.
 func (p *ParserToolbox) Locate() (*models.Location, error) {
-	if p == nil || p.Lexer == nil || p.Lexer.CurrentLocator == nil {
+	if p == nil || p.Lexer == nil || p.tokens == nil {
 		return nil, fmt.Errorf("Failed to get the locate the parser")
 	}
+	return p.locateToken(p.token(0)), nil
+}
+
+func (p *ParserToolbox) locateToken(tk *lexer.ScannedToken) *models.Location {
 	return &models.Location{
-		Path: p.Lexer.Name,
-		Line: p.Lexer.CurrentLocator.LineNumber,
-	}, nil
+		Path:   p.Lexer.Name,
+		Line:   tk.Line,
+		Offset: tk.Position,
+	}
 }
 
 func (p *ParserToolbox) ExpectsType(tokenType lexer.Token) (*lexer.ScannedToken, *models.PddlError) {
@@ -80,15 +160,11 @@ func (p *ParserToolbox) ExpectsType(tokenType lexer.Token) (*lexer.ScannedToken,
 		return nil, p.NewPddlError("Expects failed: %v", err)
 	}
 	if tk.Type != tokenType {
-		actualTyp, err := tk.Type.ToString()
-		if err != nil {
-			return nil, p.NewPddlError("Expects failed: %v", err)
-		}
 		expectedTyp, err := tokenType.ToString()
 		if err != nil {
 			return nil, p.NewPddlError("Expects failed: %v", err)
 		}
-		return nil, p.NewPddlError("Expected [%s], got [%s]", expectedTyp, actualTyp)
+		return nil, p.newTokenError(tk, p.unexpected(tk, expectedTyp))
 	}
 	return tk, nil
 }
@@ -104,8 +180,8 @@ func (p *ParserToolbox) ExpectsText(text string) (*lexer.ScannedToken, *models.P
 	if err != nil {
 		return nil, p.NewPddlError("Expects failed: %v", err)
 	}
-	if tk.Text != text {
-		return nil, p.NewPddlError("Expected [%s], got [%s]", text, tk.Text)
+	if !strings.EqualFold(tk.Text, text) {
+		return nil, p.newTokenError(tk, p.unexpected(tk, text))
 	}
 	return tk, nil
 }
@@ -122,8 +198,8 @@ func (p *ParserToolbox) Expects(args ...string) *models.PddlError {
 		if err != nil {
 			return p.NewPddlError("Expects failed: %v", err)
 		}
-		if tk.Text != val {
-			return p.NewPddlError("Expected [%s], got [%s]", val, tk.Text)
+		if !strings.EqualFold(tk.Text, val) {
+			return p.newTokenError(tk, p.unexpected(tk, val))
 		}
 	}
 	return nil
@@ -136,17 +212,46 @@ func (p *ParserToolbox) PeekNth(n int) (*lexer.ScannedToken, *models.PddlError)
 			Error:    fmt.Errorf("Peek nth failed: critical pointers are nil"),
 		}
 	}
-	if n > len(p.Peeks) {
-		panic("Max peeking threshold surpassed")
+	if n < 1 {
+		return nil, p.NewPddlError("Failed to peek at %dth token", n)
 	}
-	for ; p.nPeeks < n; p.nPeeks++ {
-		tk, err := p.Lexer.ScanToken()
-		if err != nil {
-			return nil, p.NewPddlError("Failed to peek at %dth token: %v", n, err)
+	return p.token(n - 1), nil
+}
+
+// skipList skips the list that starts with the next token.
+func (p *ParserToolbox) skipList() {
+	end, ok := p.ends[p.consumed]
+	if !ok {
+		return
+	}
+	delete(p.tried, p.consumed-1)
+	p.consumed = end + 1
+}
+
+// skipUnknown skips the sections whose keyword, such as :constraints
+// in a domain, isn't known. A keyword that looks like a misspelled
+// known one is an error.
+func (p *ParserToolbox) skipUnknown(known []string) *models.PddlError {
+	for {
+		open, kw := p.token(0), p.token(1)
+		if open.Type != lexer.TOKEN_OPEN || kw.Type != lexer.TOKEN_CATEGORY_NAME {
+			return nil
 		}
-		p.Peeks[p.nPeeks] = tk
+		for _, k := range known {
+			if strings.EqualFold(k, kw.Text) {
+				return nil
+			}
+		}
+		if k := suggest(kw.Text, known); k != "" && !unsupportedSections[strings.ToLower(kw.Text)] {
+			msg := fmt.Sprintf("Unknown section %s; did you mean %s?", kw.Text, k)
+			if q := p.quote(kw); q != "" {
+				msg += "\n" + q
+			}
+			return p.newTokenError(kw, msg)
+		}
+		p.Skipped = append(p.Skipped, p.keyword(kw, p.locateToken(kw)))
+		p.skipList()
 	}
-	return p.Peeks[n-1], nil
 }
 
 func (p *ParserToolbox) Peek() (*lexer.ScannedToken, *models.PddlError) {
@@ -187,6 +292,8 @@ func (p *ParserToolbox) AcceptsToken(tokenType lexer.Token) (*lexer.ScannedToken
 		return nil, false, p.NewPddlError("Failed to check if token is accepted: %s", err.Error.Error())
 	}
 	if tk.Type != tokenType {
+		typ, _ := tokenType.ToString()
+		p.try(0, typ)
 		return &lexer.ScannedToken{}, false, nil
 	}
 	tk, err2 := p.Next()
@@ -196,26 +303,46 @@ func (p *ParserToolbox) AcceptsToken(tokenType lexer.Token) (*lexer.ScannedToken
 	return tk, true, nil
 }
 
-func (p *ParserToolbox) Accepts(strings ...string) (bool, *models.PddlError) {
+// AcceptsNumber reads the next token when it is a number, and returns
+// its value. A number out of range is an error, and isn't read.
+func (p *ParserToolbox) AcceptsNumber() (*models.Number, bool, *models.PddlError) {
+	tk, err := p.Peek()
+	if err != nil {
+		return nil, false, p.NewPddlError("Failed to check if number is accepted: %s", err.Error.Error())
+	}
+	if tk.Type != lexer.TOKEN_NUMBER {
+		p.try(0, "number")
+		return nil, false, nil
+	}
+	n, err2 := models.NewNumber(tk.Text)
+	if err2 != nil {
+		return nil, false, p.newTokenError(tk, err2.Error())
+	}
+	_, err2 = p.Next()
+	if err2 != nil {
+		return nil, false, p.NewPddlError("Failed to check if number is accepted: %v", err2)
+	}
+	return n, true, nil
+}
+
+func (p *ParserToolbox) Accepts(texts ...string) (bool, *models.PddlError) {
 	if p == nil || p.Lexer == nil || p.Lexer.CurrentLocator == nil {
 		return false, &models.PddlError{
 			Location: nil,
 			Error:    fmt.Errorf("Accepts failed: critical pointers are nil"),
 		}
 	}
-	if len(strings) > p.Configuration.MaxPeek {
-		panic("Max peeking threshold surpassed")
-	}
-	for i := range strings {
+	for i := range texts {
 		tk, err := p.PeekNth(i + 1)
 		if err != nil {
-			return false, p.NewPddlError("Failed to check if [%s] is accepted: %s", strings[i], err.Error.Error())
+			return false, p.NewPddlError("Failed to check if [%s] is accepted: %s", texts[i], err.Error.Error())
 		}
-		if tk.Text != strings[i] {
+		if !strings.EqualFold(tk.Text, texts[i]) {
+			p.try(i, texts[i])
 			return false, nil
 		}
 	}
-	err := p.Junk(len(strings))
+	err := p.Junk(len(texts))
 	if err != nil {
 		return false, p.NewPddlError("Failed to check if strings are accepted: %v", err)
 	}
@@ -242,14 +369,7 @@ func (p *ParserToolbox) parseMultipleNames(tokenType lexer.Token) ([]*models.Nam
 		if err != nil {
 			return nil, p.NewPddlError("Failed to parse multiple names: %v", err.Error)
 		}
-		l, err2 := p.Locate()
-		if err2 != nil {
-			return nil, p.NewPddlError("Failed to parse multiple names: %v", err2)
-		}
-		ids = append(ids, &models.Name{
-			Name:     tk.Text,
-			Location: l,
-		})
+		ids = append(ids, p.name(tk, p.locateToken(tk)))
 	}
 	return ids, nil
 }
@@ -259,18 +379,40 @@ func (p *ParserToolbox) parseName(tokenType lexer.Token) (*models.Name, *models.
 	if err != nil {
 		return nil, p.NewPddlError("Failed to parse name: %v", err.Error)
 	}
-	loc, err2 := p.Locate()
-	if err2 != nil {
-		return nil, &models.PddlError{
-			Location: nil,
-			Error:    err2,
-		}
+	return p.name(t, p.locateToken(t)), nil
+}
+
+// name returns the name of a token: keywords such as requirements are
+// lower-cased, identifiers are normalized according to the identifiers
+// policy of the configuration. The spelling is kept for printing.
+func (p *ParserToolbox) name(tk *lexer.ScannedToken, loc *models.Location) *models.Name {
+	if tk.Type == lexer.TOKEN_CATEGORY_NAME {
+		return p.keyword(tk, loc)
 	}
+	n := &models.Name{
+		Name:     tk.Text,
+		Location: loc,
+	}
+	if p.Configuration.Identifiers == config.IdentifiersLower {
+		n.Name = strings.ToLower(tk.Text)
+	}
+	if n.Name != tk.Text {
+		n.Original = tk.Text
+	}
+	return n
+}
 
-	return &models.Name{
-		Name:     t.Text,
+// keyword returns the lower-cased name of a keyword token, such as an
+// assignment operator, whatever the identifiers policy.
+func (p *ParserToolbox) keyword(tk *lexer.ScannedToken, loc *models.Location) *models.Name {
+	n := &models.Name{
+		Name:     strings.ToLower(tk.Text),
 		Location: loc,
-	}, nil
+	}
+	if n.Name != tk.Text {
+		n.Original = tk.Text
+	}
+	return n
 }
 
 func (p *ParserToolbox) parseFunctionTypedList() (funs []*models.Function) {
@@ -293,44 +435,62 @@ func (p *ParserToolbox) parseFunctionTypedList() (funs []*models.Function) {
 	return
 }
 
+// parseFunctionType parses the return type of functions: number, or
+// a type for object fluents.
 func (p *ParserToolbox) parseFunctionType() (typ []*models.TypeName) {
-	ok, _ := p.Accepts("-")
-	if !ok {
-		return
-	}
-	ls, _ := p.Locate()
-	n, _ := p.ExpectsText("number")
-	return []*models.TypeName{
-		{
-			Name: &models.Name{
-				Location: ls,
-				Name:     n.Text,
-			},
-		},
-	}
+	typ, _ = p.parseType()
+	return
 }
 
-func (p *ParserToolbox) parseActionDef() *models.Action {
+// parseActionDef parses an action, or a PDDL+ process or event
+// depending on kind.
+func (p *ParserToolbox) parseActionDef(kind string) (*models.Action, *models.PddlError) {
 	act := &models.Action{}
-	p.Expects("(", ":action")
-	defer p.Expects(")")
-	act.Name, _ = p.parseName(lexer.TOKEN_NAME)
-	act.Params = p.parseActionParams()
+	err := p.Expects("(", kind)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse %s: %v", kind[1:], err.Error)
+	}
+	act.Name, err = p.parseName(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse %s: %v", kind[1:], err.Error)
+	}
+	defer p.within("%s '%s'", kind[1:], act.Name.Name)()
+	var agent []*models.TypedEntry
+	kw, _ := p.Peek()
+	if ok, _ := p.Accepts(":agent"); ok {
+		act.Agent = true
+		agent, err = p.parseTypedListString(lexer.TOKEN_VARIABLE_NAME)
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse %s %s: %v", kind[1:], act.Name.Name, err.Error)
+		}
+		if len(agent) != 1 {
+			return nil, p.newTokenError(kw, fmt.Sprintf("Failed to parse %s %s: :agent takes one typed variable, got %d", kind[1:], act.Name.Name, len(agent)))
+		}
+	}
+	act.Params = append(agent, p.parseActionParams()...)
 	ok, _ := p.Accepts(":precondition")
 	if ok {
+		leave := p.within(":precondition")
 		ok2, _ := p.Accepts("(", ")")
 		if !ok2 {
 			act.Precondition = parsePreGd(p)
 		}
+		leave()
 	}
 	ok, _ = p.Accepts(":effect")
 	if ok {
+		leave := p.within(":effect")
 		ok2, _ := p.Accepts("(", ")")
 		if !ok2 {
 			act.Effect, _ = parseEffect(p)
 		}
+		leave()
+	}
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse %s %s: %v", kind[1:], act.Name.Name, err.Error)
 	}
-	return act
+	return act, nil
 }
 
 func (p *ParserToolbox) parseActionParams() (parms []*models.TypedEntry) {
@@ -341,14 +501,11 @@ func (p *ParserToolbox) parseActionParams() (parms []*models.TypedEntry) {
 }
 
 func parsePreGd(p *ParserToolbox) models.Formula {
-	ok, _ := p.Accepts("(", "and")
-	ok2, _ := p.Accepts("(", "forall")
-
-	switch {
-	case ok:
+	if ok, _ := p.Accepts("(", "and"); ok {
 		f, _ := p.parseAndGd(parsePreGd)
 		return f
-	case ok2:
+	}
+	if ok, _ := p.Accepts("(", "forall"); ok {
 		f := p.parseForAllGd(parsePreGd)
 		return f
 	}
@@ -424,6 +581,34 @@ func (p *ParserToolbox) parseTypedListString(tokenType lexer.Token) ([]*models.T
 	return typedList, nil
 }
 
+// parsePrivateTypedList parses a typed list of names followed by
+// the MA-PDDL private ones, in a (:private ...) group.
+func (p *ParserToolbox) parsePrivateTypedList() ([]*models.TypedEntry, *models.PddlError) {
+	tls, err := p.parseTypedListString(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := p.Accepts("(", ":private")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse private names: %v", err.Error)
+	}
+	if !ok {
+		return tls, nil
+	}
+	private, err := p.parseTypedListString(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse private names: %v", err.Error)
+	}
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse private names: %v", err.Error)
+	}
+	for _, te := range private {
+		te.Private = true
+	}
+	return append(tls, private...), nil
+}
+
 func (p *ParserToolbox) parseFuncsDef() []*models.Function {
 	ok, _ := p.Accepts("(", ":functions")
 	if ok {
@@ -433,15 +618,73 @@ func (p *ParserToolbox) parseFuncsDef() []*models.Function {
 	return nil
 }
 
-func (p *ParserToolbox) parseActionsDef() ([]*models.Action) {
-	acts := []*models.Action{}
+// parseStructuresDef parses the actions of the domain, with the
+// processes and events allowed by :time and the tasks and methods
+// allowed by :hierarchy.
+func (p *ParserToolbox) parseStructuresDef(d *models.Domain) *models.PddlError {
+	reqs := map[string]bool{}
+	for _, r := range d.Requirements {
+		reqs[r.Name] = true
+	}
+	gates := map[string]string{
+		":process": ":time",
+		":event":   ":time",
+		":task":    ":hierarchy",
+		":method":  ":hierarchy",
+	}
 	tk, _ := p.Peek()
 	for tk.Type == lexer.TOKEN_OPEN {
-		act := p.parseActionDef()
-		acts = append(acts, act)
+		err := p.skipUnknown(domainSections)
+		if err != nil {
+			return p.NewPddlError("Failed to parse domain structures: %v", err.Error)
+		}
+		if tk, _ = p.Peek(); tk.Type != lexer.TOKEN_OPEN {
+			break
+		}
+		kind, err := p.PeekNth(2)
+		if err != nil {
+			return p.NewPddlError("Failed to parse domain structures: %v", err.Error)
+		}
+		section := strings.ToLower(kind.Text)
+		if req, ok := gates[section]; ok && !reqs[req] {
+			return p.NewPddlError("Failed to parse domain structures: %s requires %s", kind.Text, req)
+		}
+		switch section {
+		case ":process", ":event":
+			act, err := p.parseActionDef(section)
+			if err != nil {
+				return p.NewPddlError("Failed to parse domain structures: %v", err.Error)
+			}
+			if section == ":process" {
+				d.Processes = append(d.Processes, act)
+			} else {
+				d.Events = append(d.Events, act)
+			}
+		case ":task":
+			t, err := p.parseTaskDef()
+			if err != nil {
+				return p.NewPddlError("Failed to parse domain structures: %v", err.Error)
+			}
+			d.Tasks = append(d.Tasks, t)
+		case ":method":
+			m, err := p.parseMethodDef()
+			if err != nil {
+				return p.NewPddlError("Failed to parse domain structures: %v", err.Error)
+			}
+			d.Methods = append(d.Methods, m)
+		default:
+			act, err := p.parseActionDef(":action")
+			if err != nil {
+				return p.NewPddlError("Failed to parse domain structures: %v", err.Error)
+			}
+			if act.Agent && !reqs[":multi-agent"] {
+				return p.NewPddlError("Failed to parse action %s: :agent requires :multi-agent", act.Name.Name)
+			}
+			d.Actions = append(d.Actions, act)
+		}
 		tk, _ = p.Peek()
 	}
-	return acts
+	return nil
 }
 
 func (p *ParserToolbox) parseType() ([]*models.TypeName, *models.PddlError) {
@@ -490,6 +733,7 @@ func (p *ParserToolbox) parseTypesDefinition() ([]*models.Type, *models.PddlErro
 	ok, err := p.Accepts("(", ":types")
 	if ok {
 		defer p.Expects(")")
+		defer p.within(":types")()
 		tls, err := p.parseTypedListString(lexer.TOKEN_NAME)
 		if err != nil {
 			return nil, p.NewPddlError("Failed to parse types definition: %v", err.Error)
@@ -510,7 +754,8 @@ func (p *ParserToolbox) parseConstantsDefinition() ([]*models.TypedEntry, *model
 	ok, err := p.Accepts("(", ":constants")
 	if ok {
 		defer p.Expects(")")
-		tls, err := p.parseTypedListString(lexer.TOKEN_NAME)
+		defer p.within(":constants")()
+		tls, err := p.parsePrivateTypedList()
 		if err != nil {
 			return nil, p.NewPddlError("Failed to parse constants definition: %v", err.Error)
 		}
@@ -526,25 +771,23 @@ func (p *ParserToolbox) parsePredicatesDefinition() ([]*models.Predicate, *model
 	ok, err := p.Accepts("(", ":predicates")
 	if ok {
 		defer p.Expects(")")
-		pd, err := p.parseAtomicPred()
-		if err != nil {
-			return nil, p.NewPddlError("Failed to parse predicates definition: %v", err.Error)
-		}
-		preds := []*models.Predicate{pd}
+		defer p.within(":predicates")()
+		preds := []*models.Predicate{}
 		tk, err := p.Peek()
-		if err != nil {
-			return nil, p.NewPddlError("Failed to parse predicates definition: %v", err.Error)
-		}
-		for tk.Type == lexer.TOKEN_OPEN {
-			pd, err = p.parseAtomicPred()
+		for err == nil && tk.Type == lexer.TOKEN_OPEN {
+			var ps []*models.Predicate
+			ps, err = p.parseAtomicPreds()
 			if err != nil {
 				return nil, p.NewPddlError("Failed to parse predicates definition: %v", err.Error)
 			}
+			preds = append(preds, ps...)
 			tk, err = p.Peek()
-			if err != nil {
-				return nil, p.NewPddlError("Failed to parse predicates definition: %v", err.Error)
-			}
-			preds = append(preds, pd)
+		}
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse predicates definition: %v", err.Error)
+		}
+		if len(preds) == 0 {
+			return nil, p.NewPddlError("Failed to parse predicates definition: no predicate")
 		}
 		return preds, nil
 	}
@@ -554,6 +797,42 @@ func (p *ParserToolbox) parsePredicatesDefinition() ([]*models.Predicate, *model
 	return nil, nil
 }
 
+// parseAtomicPreds parses a predicate, or the MA-PDDL private
+// predicates of a (:private ...) group.
+func (p *ParserToolbox) parseAtomicPreds() ([]*models.Predicate, *models.PddlError) {
+	ok, err := p.Accepts("(", ":private")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse predicates: %v", err.Error)
+	}
+	if !ok {
+		pd, err := p.parseAtomicPred()
+		if err != nil {
+			return nil, err
+		}
+		return []*models.Predicate{pd}, nil
+	}
+	preds := []*models.Predicate{}
+	tk, err := p.Peek()
+	for err == nil && tk.Type == lexer.TOKEN_OPEN {
+		var pd *models.Predicate
+		pd, err = p.parseAtomicPred()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse private predicates: %v", err.Error)
+		}
+		pd.Private = true
+		preds = append(preds, pd)
+		tk, err = p.Peek()
+	}
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse private predicates: %v", err.Error)
+	}
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse private predicates: %v", err.Error)
+	}
+	return preds, nil
+}
+
 func (p *ParserToolbox) parseAtomicPred() (*models.Predicate, *models.PddlError) {
 	err := p.Expects("(")
 	if err != nil {
@@ -615,32 +894,195 @@ func (p *ParserToolbox) parseAssign() (*models.AssignNode, *models.PddlError) {
 	}
 	defer p.Expects(")")
 	assignNode := &models.AssignNode{}
-	assignNode.Operation, err = p.parseName(lexer.TOKEN_NAME)
+	op, err := p.ExpectsType(lexer.TOKEN_NAME)
 	if err != nil {
 		return nil, p.NewPddlError("Failed to parse assignment operation: %v", err.Error)
 	}
+	assignNode.Operation = p.keyword(op, p.locateToken(op))
 	assignNode.AssignedTo, err = p.parseFunctioninit()
 	if err != nil {
 		return nil, p.NewPddlError("Failed to parse assignment operation: %v", err)
 	}
-	if n, ok, err := p.AcceptsToken(lexer.TOKEN_NAME); ok {
+	tk, err := p.Peek()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse assignment operation: %v", err.Error)
+	}
+	if tk.Type == lexer.TOKEN_VARIABLE_NAME || (tk.Type == lexer.TOKEN_NAME && assignNode.Operation.Name == "assign") {
+		// Object fluents are assigned terms
+		terms, err := p.parseTerms()
 		if err != nil {
-			return nil, p.NewPddlError("Failed to parse assignment operation: %v", err)
+			return nil, p.NewPddlError("Failed to parse assignment operation: %v", err.Error)
 		}
+		assignNode.Term = terms[0]
+		return assignNode, nil
+	}
+	expr, err := p.parseNumericExpression()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse assignment operation: %v", err.Error)
+	}
+	switch {
+	case expr.IsNumber:
 		assignNode.IsNumber = true
-		assignNode.Number = n.Text
-	} else {
-		assignNode.FunctionInit, err = p.parseFunctioninit()
+		assignNode.Number = expr.Number
+	case expr.FunctionInit != nil:
+		assignNode.FunctionInit = expr.FunctionInit
+	default:
+		assignNode.Expression = expr
+	}
+	return assignNode, nil
+}
+
+func (p *ParserToolbox) parseNumericExpression() (*models.NumericExpression, *models.PddlError) {
+	loc, err := p.Locate()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse numeric expression: %v", err)
+	}
+	expr := &models.NumericExpression{
+		Node: &models.Node{
+			Location: loc,
+		},
+	}
+	n, ok, err2 := p.AcceptsNumber()
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse numeric expression: %v", err2.Error)
+	}
+	if ok {
+		expr.IsNumber = true
+		expr.Number = n
+		return expr, nil
+	}
+	tk, err2 := p.Peek()
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse numeric expression: %v", err2.Error)
+	}
+	if tk.Type == lexer.TOKEN_OPEN {
+		tk, err2 = p.PeekNth(2)
+		if err2 != nil {
+			return nil, p.NewPddlError("Failed to parse numeric expression: %v", err2.Error)
+		}
+		if _, ok := models.ArithmeticOps[tk.Text]; ok {
+			p.Junk(2)
+			expr.Operation = &models.Name{
+				Name:     tk.Text,
+				Location: loc,
+			}
+			tk, err2 = p.Peek()
+			for err2 == nil && tk.Type != lexer.TOKEN_CLOSE && tk.Type != lexer.TOKEN_EOF {
+				o, err3 := p.parseNumericExpression()
+				if err3 != nil {
+					return nil, p.NewPddlError("Failed to parse numeric expression: %v", err3.Error)
+				}
+				expr.Operands = append(expr.Operands, o)
+				tk, err2 = p.Peek()
+			}
+			if err2 != nil {
+				return nil, p.NewPddlError("Failed to parse numeric expression: %v", err2.Error)
+			}
+			err2 = p.Expects(")")
+			if err2 != nil {
+				return nil, p.NewPddlError("Failed to parse numeric expression: %v", err2.Error)
+			}
+			if len(expr.Operands) == 0 {
+				return nil, p.NewPddlError("Failed to parse numeric expression: operation %s has no operand", tk.Text)
+			}
+			return expr, nil
+		}
+	}
+	expr.FunctionInit, err2 = p.parseFunctioninit()
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse numeric expression: %v", err2.Error)
+	}
+	return expr, nil
+}
+
+// acceptsComparison consumes the opening of a binary comparison
+// such as "(<=" and returns the operator.
+func (p *ParserToolbox) acceptsComparison() (*models.Name, bool) {
+	tk, err := p.Peek()
+	if err != nil || tk.Type != lexer.TOKEN_OPEN {
+		return nil, false
+	}
+	tk, err = p.PeekNth(2)
+	if err != nil {
+		return nil, false
+	}
+	if _, ok := models.CompareOps[tk.Text]; !ok {
+		return nil, false
+	}
+	loc, _ := p.Locate()
+	p.Junk(2)
+	return &models.Name{
+		Name:     tk.Text,
+		Location: loc,
+	}, true
+}
+
+// parseComparisonGd parses the remainder of a comparison whose
+// operator was already consumed. "=" between terms is an equality
+// literal rather than a numeric comparison.
+func (p *ParserToolbox) parseComparisonGd(op *models.Name) (models.Formula, *models.PddlError) {
+	defer p.Expects(")")
+	tk, err := p.Peek()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse comparison: %v", err.Error)
+	}
+	if op.Name == "=" && (tk.Type == lexer.TOKEN_NAME || tk.Type == lexer.TOKEN_VARIABLE_NAME) {
+		terms, err := p.parseTerms()
 		if err != nil {
-			return nil, p.NewPddlError("Failed to parse assignment operation: %v", err)
+			return nil, p.NewPddlError("Failed to parse comparison: %v", err.Error)
 		}
+		return &models.LiteralNode{
+			Node: &models.Node{
+				Location: op.Location,
+			},
+			Predicate: op,
+			Terms:     terms,
+		}, nil
 	}
-	return assignNode, nil
+	left, err := p.parseNumericExpression()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse comparison: %v", err.Error)
+	}
+	tk, err = p.Peek()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse comparison: %v", err.Error)
+	}
+	if op.Name == "=" && left.FunctionInit != nil && (tk.Type == lexer.TOKEN_NAME || tk.Type == lexer.TOKEN_VARIABLE_NAME) {
+		// Object fluent compared to a term
+		terms, err := p.parseTerms()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse comparison: %v", err.Error)
+		}
+		return &models.LiteralNode{
+			Node: &models.Node{
+				Location: op.Location,
+			},
+			Predicate: op,
+			Terms: append([]*models.Term{
+				{
+					Name:     left.FunctionInit.Name,
+					Function: left.FunctionInit,
+				},
+			}, terms...),
+		}, nil
+	}
+	right, err := p.parseNumericExpression()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse comparison: %v", err.Error)
+	}
+	return &models.CompareNode{
+		Node: &models.Node{
+			Location: op.Location,
+		},
+		Operation: op,
+		Left:      left,
+		Right:     right,
+	}, nil
 }
 
 func (p *ParserToolbox) parseForAllEffect(nestedFormula func(*ParserToolbox) (models.Formula, *models.PddlError)) (models.Formula, *models.PddlError) {
 	defer p.Expects(")")
-	loc, err := p.Locate()
+	loc, err := p.locateList()
 	if err != nil {
 		return nil, p.NewPddlError("Failed to parse for all effect: %v", err)
 	}
@@ -669,7 +1111,7 @@ func (p *ParserToolbox) parseAndGd(nested func(*ParserToolbox) models.Formula) (
 	if err != nil {
 		return nil, p.NewPddlError("Failed to parse and grounded: %v", err.Error)
 	}
-	l, err2 := p.Locate()
+	l, err2 := p.locateList()
 	if err2 != nil {
 		return nil, p.NewPddlError("Failed to parse and grounded: %v", err.Error)
 	}
@@ -702,7 +1144,7 @@ func (p *ParserToolbox) parseFormulaStar(nested func(*ParserToolbox) models.Form
 
 func (p *ParserToolbox) parseWhenEffect(nestedFormula func(*ParserToolbox) models.Formula) (models.Formula, *models.PddlError) {
 	defer p.Expects(")")
-	loc, err := p.Locate()
+	loc, err := p.locateList()
 	if err != nil {
 		return nil, p.NewPddlError("Failed to parse when effect: %v", err)
 	}
@@ -725,7 +1167,7 @@ func parsePrefGd(p *ParserToolbox) models.Formula {
 func parseOrGd(p *ParserToolbox, nested func(*ParserToolbox) models.Formula) models.Formula {
 	defer p.Expects(")")
 	f, _ := p.parseFormulaStar(nested)
-	l, _ := p.Locate()
+	l, _ := p.locateList()
 	return &models.OrNode{
 		MultiNode: &models.MultiNode{
 			Node: models.Node{
@@ -738,7 +1180,7 @@ func parseOrGd(p *ParserToolbox, nested func(*ParserToolbox) models.Formula) mod
 
 func (p *ParserToolbox) parseNotGd() models.Formula {
 	defer p.Expects(")")
-	l, _ := p.Locate()
+	l, _ := p.locateList()
 	return &models.NotNode{
 		UnaryNode: &models.UnaryNode{
 			Node: &models.Node{
@@ -751,7 +1193,7 @@ func (p *ParserToolbox) parseNotGd() models.Formula {
 
 func (p *ParserToolbox) parseImplyGd() models.Formula {
 	defer p.Expects(")")
-	l, _ := p.Locate()
+	l, _ := p.locateList()
 	return &models.ImplyNode{
 		BinaryNode: &models.BinaryNode{
 			Node: models.Node{
@@ -765,7 +1207,7 @@ func (p *ParserToolbox) parseImplyGd() models.Formula {
 
 func (p *ParserToolbox) parseForAllGd(nested func(*ParserToolbox) models.Formula) models.Formula {
 	defer p.Expects(")")
-	l, _ := p.Locate()
+	l, _ := p.locateList()
 	return &models.ForAllNode{
 		QuantNode: &models.QuantNode{
 			Variables: p.parseQuantVariables(),
@@ -789,7 +1231,7 @@ func (p *ParserToolbox) parseQuantVariables() []*models.TypedEntry {
 
 func (p *ParserToolbox) parseExistsGd(nested func(*ParserToolbox) models.Formula) models.Formula {
 	defer p.Expects(")")
-	loc, _ := p.Locate()
+	loc, _ := p.locateList()
 	return &models.ExistsNode{
 		QuantNode: &models.QuantNode{
 			Variables: p.parseQuantVariables(),
@@ -804,37 +1246,40 @@ func (p *ParserToolbox) parseExistsGd(nested func(*ParserToolbox) models.Formula
 }
 
 func parseGd(p *ParserToolbox) models.Formula {
-	ok, _ := p.Accepts("(", "and")
-	ok2, _ := p.Accepts("(", "or")
-	ok3, _ := p.Accepts("(", "not")
-	ok4, _ := p.Accepts("(", "imply")
-	ok5, _ := p.Accepts("(", "exists")
-	ok6, _ := p.Accepts("(", "forall")
-
-	switch {
-	case ok:
+	// Keywords are checked one at a time: a successful Accepts
+	// consumes its tokens.
+	if ok, _ := p.Accepts("(", "and"); ok {
 		x, _ := p.parseAndGd(parseGd)
 		return x
-	case ok2:
+	}
+	if ok, _ := p.Accepts("(", "or"); ok {
 		x := parseOrGd(p, parseGd)
 		return x
-	case ok3:
+	}
+	if ok, _ := p.Accepts("(", "not"); ok {
 		x := p.parseNotGd()
-		if lit, ok := x.(*models.LiteralNode); ok {
+		if lit, ok := x.(*models.NotNode).UnaryNode.Formula.(*models.LiteralNode); ok {
 			lit.Negative = !lit.Negative
 			return lit
 		}
 		return x
-	case ok4:
+	}
+	if ok, _ := p.Accepts("(", "imply"); ok {
 		x := p.parseImplyGd()
 		return x
-	case ok5:
+	}
+	if ok, _ := p.Accepts("(", "exists"); ok {
 		x := p.parseExistsGd(parseGd)
 		return x
-	case ok6:
+	}
+	if ok, _ := p.Accepts("(", "forall"); ok {
 		x := p.parseForAllGd(parseGd)
 		return x
 	}
+	if op, ok := p.acceptsComparison(); ok {
+		x, _ := p.parseComparisonGd(op)
+		return x
+	}
 
 	x, _ := p.parseLitteral(false)
 	return x
@@ -845,7 +1290,7 @@ func parsePEffect(p *ParserToolbox) models.Formula {
 	if err != nil {
 		return nil
 	}
-	if _, ok := models.AssignOps[tk.Text]; ok {
+	if _, ok := models.AssignOps[strings.ToLower(tk.Text)]; ok {
 		tk2, err := p.Peek()
 		if err != nil {
 			return nil
@@ -881,7 +1326,7 @@ func (p *ParserToolbox) parseLitteral(effect bool) (*models.LiteralNode, *models
 	}
 	defer p.Expects(")")
 
-	l, err2 := p.Locate()
+	l, err2 := p.locateList()
 	if err2 != nil {
 		return nil, p.NewPddlError("Failed to parse litteral: %v", err.Error)
 	}
@@ -924,10 +1369,7 @@ func (p *ParserToolbox) parseTerms() ([]*models.Term, *models.PddlError) {
 		}
 		if ok {
 			terms = append(terms, &models.Term{
-				Name: &models.Name{
-					Name:     t.Text,
-					Location: l,
-				},
+				Name: p.name(t, l),
 			})
 			continue
 		}
@@ -937,10 +1379,23 @@ func (p *ParserToolbox) parseTerms() ([]*models.Term, *models.PddlError) {
 		}
 		if ok {
 			terms = append(terms, &models.Term{
-				Name: &models.Name{
-					Name:     t.Text,
-					Location: l,
-				},
+				Name: p.name(t, l),
+			})
+			continue
+		}
+		t, err2 = p.Peek()
+		if err2 != nil {
+			return nil, p.NewPddlError("Failed to parse terms: %v", err2.Error)
+		}
+		if t.Type == lexer.TOKEN_OPEN {
+			// Function term of an object fluent
+			fi, err2 := p.parseFunctioninit()
+			if err2 != nil {
+				return nil, p.NewPddlError("Failed to parse terms: %v", err2.Error)
+			}
+			terms = append(terms, &models.Term{
+				Name:     fi.Name,
+				Function: fi,
 			})
 			continue
 		}
@@ -950,19 +1405,108 @@ func (p *ParserToolbox) parseTerms() ([]*models.Term, *models.PddlError) {
 }
 
 func parseConditionalEffect(p *ParserToolbox) models.Formula {
-	ok, _ := p.Accepts("(", "forall")
-	ok2, _ := p.Accepts("(", "when") 
-	switch {
-	case ok:
+	if ok, _ := p.Accepts("(", "oneof"); ok {
+		f, _ := p.parseOneOfEffect(func(p *ParserToolbox) models.Formula {
+			f, _ := parseEffect(p)
+			return f
+		})
+		return f
+	}
+	if ok, _ := p.Accepts("(", "probabilistic"); ok {
+		f, _ := p.parseProbabilisticEffect()
+		return f
+	}
+	if ok, _ := p.Accepts("(", "forall"); ok {
 		f, _ := p.parseForAllEffect(parseEffect)
 		return f
-	case ok2:
+	}
+	if ok, _ := p.Accepts("(", "when"); ok {
 		f, _ := p.parseWhenEffect(parseAndOrPreEffect)
 		return f
 	}
 	return parsePEffect(p)
 }
 
+// parseOneOfEffect parses the alternatives of a FOND effect whose
+// opening was already consumed.
+func (p *ParserToolbox) parseOneOfEffect(nested func(*ParserToolbox) models.Formula) (models.Formula, *models.PddlError) {
+	loc, _ := p.locateList()
+	defer p.Expects(")")
+	fs, err := p.parseFormulaStar(nested)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse oneof effect: %v", err.Error)
+	}
+	if len(fs) == 0 {
+		return nil, p.NewPddlError("Failed to parse oneof effect: no alternative")
+	}
+	return &models.OneOfNode{
+		MultiNode: &models.MultiNode{
+			Node: models.Node{
+				Location: loc,
+			},
+			Formula: fs,
+		},
+	}, nil
+}
+
+// parseProbabilisticEffect parses the outcomes of a PPDDL effect
+// whose opening was already consumed.
+func (p *ParserToolbox) parseProbabilisticEffect() (models.Formula, *models.PddlError) {
+	loc, _ := p.locateList()
+	defer p.Expects(")")
+	n := &models.ProbabilisticNode{
+		Node: &models.Node{
+			Location: loc,
+		},
+	}
+	for {
+		pr, ok, err := p.AcceptsNumber()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse probabilistic effect: %v", err.Error)
+		}
+		if !ok {
+			break
+		}
+		f, err := parseEffect(p)
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse probabilistic effect: %v", err.Error)
+		}
+		n.Outcomes = append(n.Outcomes, &models.ProbabilisticOutcome{
+			Probability: pr,
+			Formula:     f,
+		})
+	}
+	if len(n.Outcomes) == 0 {
+		return nil, p.NewPddlError("Failed to parse probabilistic effect: no outcome")
+	}
+	return n, nil
+}
+
+// checkProbabilities reports the first probabilistic effect of the
+// domain whose probabilities are invalid.
+func checkProbabilities(d *models.Domain) *models.PddlError {
+	for _, a := range d.Structures() {
+		var perr *models.PddlError
+		models.Inspect(a.Effect, func(f models.Formula) bool {
+			n, ok := f.(*models.ProbabilisticNode)
+			if !ok || perr != nil {
+				return perr == nil
+			}
+			if _, err := n.Probabilities(); err != nil {
+				perr = &models.PddlError{
+					Location: n.Node.Location,
+					Error:    fmt.Errorf("Failed to parse probabilistic effect of %s: %v", a.Name.Name, err),
+				}
+			}
+			return true
+		})
+		if perr != nil {
+			return perr
+		}
+	}
+	return nil
+}
+
 func parseEffect(p *ParserToolbox) (models.Formula, *models.PddlError){
 	ok, err := p.Accepts("(", "and")
 	if ok {
@@ -985,7 +1529,7 @@ func (p *ParserToolbox) parseAndEffect(nestedFormula func(p *ParserToolbox) mode
 	if err != nil {
 		return nil, p.NewPddlError("Failed to parse and effect: %v", err.Error)
 	}
-	l, err2 := p.Locate()
+	l, err2 := p.locateList()
 	if err2 != nil {
 		return nil, p.NewPddlError("Failed to parse and effect: %v", err.Error)
 	}
@@ -1000,6 +1544,10 @@ func (p *ParserToolbox) parseAndEffect(nestedFormula func(p *ParserToolbox) mode
 }
 
 func parseAndOrPreEffect(p *ParserToolbox) models.Formula {
+	if ok, _ := p.Accepts("(", "oneof"); ok {
+		f, _ := p.parseOneOfEffect(parseAndOrPreEffect)
+		return f
+	}
 	ok, err := p.Accepts("(", "and")
 	if err != nil {
 		return nil
@@ -1032,29 +1580,65 @@ func (p *ParserToolbox) parseProbDomain() *models.Name {
 func (p *ParserToolbox) parseObjsDecl() []*models.TypedEntry {
 	if ok, _ := p.Accepts("(", ":objects"); ok {
 		defer p.Expects(")")
-		te, _ := p.parseTypedListString(lexer.TOKEN_NAME)
+		te, _ := p.parsePrivateTypedList()
 		return te
 	}
 	return nil
 }
 
-func (p *ParserToolbox) parseInit() (els []models.Formula) {
+func (p *ParserToolbox) parseInit() ([]models.Formula, *models.PddlError) {
 	p.Expects("(", ":init")
 	defer p.Expects(")")
+	var els []models.Formula
 	tk, _ := p.Peek()
 	for tk.Type == lexer.TOKEN_OPEN {
-		els = append(els, p.parseInitEl())
+		el, err := p.parseInitEl()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse init: %v", err.Error)
+		}
+		els = append(els, el)
 		tk, _ = p.Peek()
 	}
-	return
+	return els, nil
 }
 
-func (p *ParserToolbox) parseInitEl() models.Formula {
+func (p *ParserToolbox) parseInitEl() (models.Formula, *models.PddlError) {
 	loc, _ := p.Locate()
 	if ok, _ := p.Accepts("(", "="); ok {
 		defer p.Expects(")")
-		at, _ := p.parseFunctioninit()
-		n, _ := p.ExpectsType(lexer.TOKEN_NAME)
+		at, err := p.parseFunctioninit()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse init element: %v", err.Error)
+		}
+		term, err := p.parseInitObject()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse init element: %v", err.Error)
+		}
+		if term != nil {
+			// Object fluent initialization
+			return &models.AssignNode{
+				Node: &models.Node{
+					Location: loc,
+				},
+				Operation: &models.Name{
+					Name:     "=",
+					Location: loc,
+				},
+				AssignedTo: at,
+				IsInit:     true,
+				Term:       term,
+			}, nil
+		}
+		n, ok, err := p.AcceptsNumber()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse init element: %v", err.Error)
+		}
+		if !ok {
+			// Not a number either, which is reported with the
+			// alternatives.
+			_, err = p.ExpectsType(lexer.TOKEN_NUMBER)
+			return nil, p.NewPddlError("Failed to parse init element: %v", err.Error)
+		}
 		return &models.AssignNode{
 			Node: &models.Node{
 				Location: loc,
@@ -1066,19 +1650,129 @@ func (p *ParserToolbox) parseInitEl() models.Formula {
 			AssignedTo: at,
 			IsInit:     true,
 			IsNumber:   true,
-			Number:     n.Text,
+			Number:     n,
+		}, nil
+	}
+	head, _ := p.PeekNth(2)
+	if ok, _ := p.Accepts("(", "at"); ok {
+		defer p.Expects(")")
+		t, ok, err := p.AcceptsNumber()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse init element: %v", err.Error)
 		}
+		if ok {
+			// Timed initial literal or fluent
+			f, err := p.parseInitEl()
+			if err != nil {
+				return nil, p.NewPddlError("Failed to parse timed init element: %v", err.Error)
+			}
+			return &models.TimedInitNode{
+				Node: &models.Node{
+					Location: loc,
+				},
+				Time: t,
+				UnaryNode: &models.UnaryNode{
+					Node: &models.Node{
+						Location: loc,
+					},
+					Formula: f,
+				},
+			}, nil
+		}
+		// Literal of a predicate named at
+		terms, err := p.parseTerms()
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse init element: %v", err.Error)
+		}
+		return &models.LiteralNode{
+			Node: &models.Node{
+				Location: loc,
+			},
+			Predicate: p.name(head, loc),
+			Terms:     terms,
+		}, nil
 	}
 	ln, err := p.parseLitteral(false)
 	if err != nil {
-		fmt.Printf("Failed to parse init elements: %v", err.ToError())
-		panic("Failed to parse litteral")
+		return nil, p.NewPddlError("Failed to parse init element: %v", err.Error)
 	}
-	return ln
+	return ln, nil
+}
+
+// parseInitObject parses the value of an object fluent in :init, an
+// object or a function term such as (loc t1), or returns nil when the
+// value is something else.
+func (p *ParserToolbox) parseInitObject() (*models.Term, *models.PddlError) {
+	loc, err := p.Locate()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse object: %v", err)
+	}
+	t, ok, err2 := p.AcceptsToken(lexer.TOKEN_NAME)
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse object: %v", err2.Error)
+	}
+	if ok {
+		return &models.Term{
+			Name: p.name(t, loc),
+		}, nil
+	}
+	t, err2 = p.Peek()
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse object: %v", err2.Error)
+	}
+	if t.Type != lexer.TOKEN_OPEN {
+		return nil, nil
+	}
+	fi, err2 := p.parseFunctioninit()
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse object: %v", err2.Error)
+	}
+	return &models.Term{
+		Name:     fi.Name,
+		Function: fi,
+	}, nil
 }
 
+// parseGoal parses the goal, which HDDL problems may omit.
 func (p *ParserToolbox) parseGoal() models.Formula {
-	p.Expects("(", ":goal")
+	if ok, _ := p.Accepts("(", ":goal"); !ok {
+		return nil
+	}
 	defer p.Expects(")")
 	return parsePreGd(p)
 }
+
+func (p *ParserToolbox) parseMetric() (*models.Metric, *models.PddlError) {
+	loc, err := p.Locate()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse metric: %v", err)
+	}
+	ok, err2 := p.Accepts("(", ":metric")
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse metric: %v", err2.Error)
+	}
+	if !ok {
+		return nil, nil
+	}
+	defer p.Expects(")")
+	defer p.within(":metric")()
+	tk, err2 := p.ExpectsType(lexer.TOKEN_NAME)
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse metric: %v", err2.Error)
+	}
+	dir := p.keyword(tk, p.locateToken(tk))
+	if dir.Name != "minimize" && dir.Name != "maximize" {
+		return nil, p.NewPddlError("Failed to parse metric: expected minimize or maximize, got [%s]", dir.Name)
+	}
+	expr, err2 := p.parseNumericExpression()
+	if err2 != nil {
+		return nil, p.NewPddlError("Failed to parse metric: %v", err2.Error)
+	}
+	return &models.Metric{
+		Node: &models.Node{
+			Location: loc,
+		},
+		Direction:  dir,
+		Expression: expr,
+	}, nil
+}
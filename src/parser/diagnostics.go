@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/guilyx/go-pddl/src/lexer"
+)
+
+var (
+	// keywords are the words of the grammar, against which misspelled
+	// tokens are matched.
+	keywords = []string{
+		"define", "domain", "problem", ":domain",
+		":requirements", ":types", ":constants", ":predicates", ":functions",
+		":action", ":parameters", ":precondition", ":effect",
+		":process", ":event", ":agent", ":private",
+		":task", ":method", ":htn", ":subtasks", ":ordered-subtasks",
+		":tasks", ":ordered-tasks", ":ordering", ":constraints",
+		":objects", ":init", ":goal", ":metric",
+		"and", "or", "not", "imply", "forall", "exists", "when", "either",
+		"oneof", "probabilistic", "at",
+		"assign", "increase", "decrease", "scale-up", "scale-down",
+		"minimize", "maximize",
+	}
+)
+
+var (
+	// domainSections and problemSections are the known sections,
+	// others are skipped.
+	domainSections = []string{
+		":requirements", ":types", ":constants", ":predicates", ":functions",
+		":action", ":process", ":event", ":task", ":method",
+	}
+	problemSections = []string{
+		":domain", ":requirements", ":objects", ":htn", ":init", ":goal", ":metric",
+	}
+	// unsupportedSections are PDDL sections that are skipped without
+	// being taken for typos.
+	unsupportedSections = map[string]bool{
+		":constraints":     true,
+		":derived":         true,
+		":durative-action": true,
+		":length":          true,
+	}
+)
+
+// maxTypoDistance is the largest edit distance at which a token is
+// taken for a misspelled keyword.
+const maxTypoDistance = 2
+
+// within enters a grammar context, such as ":precondition" or
+// "action 'drive'", until the returned function is called. The
+// contexts are described in the diagnostics.
+func (p *ParserToolbox) within(format string, args ...interface{}) func() {
+	p.contexts = append(p.contexts, fmt.Sprintf(format, args...))
+	n := len(p.contexts)
+	return func() {
+		p.contexts = p.contexts[:n-1]
+	}
+}
+
+// try remembers that text was acceptable as the nth next token.
+func (p *ParserToolbox) try(n int, text string) {
+	pos := p.consumed + n
+	for _, t := range p.tried[pos] {
+		if t == text {
+			return
+		}
+	}
+	p.tried[pos] = append(p.tried[pos], text)
+}
+
+// unexpected describes a token that isn't the expected one: the
+// grammar context, the acceptable tokens, a suggestion when the token
+// looks like a misspelled keyword, and the quoted source line.
+func (p *ParserToolbox) unexpected(tk *lexer.ScannedToken, expected string) string {
+	got := tk.Text
+	if tk.Type == lexer.TOKEN_EOF {
+		got = "end of file"
+	}
+	s := fmt.Sprintf("Expected [%s], got [%s]", expected, got)
+	if len(p.contexts) > 0 {
+		cs := []string{}
+		for i := len(p.contexts) - 1; i >= 0; i-- {
+			cs = append(cs, p.contexts[i])
+		}
+		s += " in " + strings.Join(cs, " of ")
+	}
+	// The token being checked was consumed already.
+	alts := append([]string{}, p.tried[p.consumed-1]...)
+	found := false
+	for _, a := range alts {
+		found = found || a == expected
+	}
+	if !found {
+		alts = append(alts, expected)
+	}
+	if len(alts) > 1 {
+		s += "; acceptable: " + strings.Join(alts, ", ")
+	}
+	if tk.Type == lexer.TOKEN_NAME || tk.Type == lexer.TOKEN_CATEGORY_NAME {
+		if k := suggest(tk.Text, alts); k != "" {
+			s += fmt.Sprintf("; did you mean %s?", k)
+		}
+	}
+	if q := p.quote(tk); q != "" {
+		s += "\n" + q
+	}
+	return s
+}
+
+// quote returns the source line of the token, with a caret under it,
+// unless the text was streamed.
+func (p *ParserToolbox) quote(tk *lexer.ScannedToken) string {
+	return p.Lexer.Quote(tk)
+}
+
+// suggest returns the acceptable token, or else the keyword, closest
+// to the text when it is close enough to be a typo.
+func suggest(text string, alts []string) string {
+	for _, cands := range [][]string{alts, keywords} {
+		best, bestDist := "", maxTypoDistance+1
+		for _, c := range cands {
+			d := distance(strings.ToLower(text), c)
+			if d > 0 && d < bestDist && d < len(c)/2 {
+				best, bestDist = c, d
+			}
+		}
+		if best != "" {
+			return best
+		}
+	}
+	return ""
+}
+
+// distance is the Levenshtein distance between two strings.
+func distance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+)
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		want   string
+		quote  string
+	}{
+		{
+			"typo",
+			"    :parameters (?x)\n    :preconditon (clear ?x)\n",
+			"Expected [)], got [:preconditon] in action 'pick-up'; acceptable: :precondition, :effect, ); did you mean :precondition?",
+			"    6 |     :preconditon (clear ?x)\n      |     ^^^^^^^^^^^^",
+		},
+		{
+			"tabs",
+			"\t:parameters (?x)\n\t:precondition (clear ?x)\n\t:efect (not (clear ?x))\n",
+			"did you mean :effect?",
+			"    7 | \t:efect (not (clear ?x))\n      | \t^^^^^^",
+		},
+		{
+			"no typo",
+			"    :parameters (?x)\n    :precondition (clear ?x)\n    (clear ?x)\n",
+			"Expected [)], got [(] in action 'pick-up'",
+			"    7 |     (clear ?x)\n      |     ^",
+		},
+	}
+	problem := `(define (problem p) (:domain blocks) (:objects a) (:init (clear a)) (:goal (clear a)))`
+	for _, test := range tests {
+		domain := "(define (domain blocks)\n  (:requirements :strips)\n  (:predicates (clear ?x))\n  (:action pick-up\n" +
+			test.action + "))\n"
+		_, _, err := ParseTexts(&config.Config{}, domain, problem)
+		if err == nil {
+			t.Errorf("%s: got no error", test.name)
+			continue
+		}
+		msg := err.Error()
+		if !strings.Contains(msg, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, msg, test.want)
+		}
+		if !strings.HasSuffix(msg, "\n"+test.quote) {
+			t.Errorf("%s: got %q, want the quote %q", test.name, msg, test.quote)
+		}
+		if test.name == "no typo" && strings.Contains(msg, "did you mean") {
+			t.Errorf("%s: got a suggestion in %q", test.name, msg)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		text string
+		alts []string
+		want string
+	}{
+		{":efect", []string{":effect", ")"}, ":effect"},
+		{":PRECONDITON", []string{":precondition"}, ":precondition"},
+		{"forall", []string{")"}, ""},
+		{"fral", nil, "forall"},
+		{"ad", nil, ""},
+		{":goall", nil, ":goal"},
+		{"xyz", []string{"and"}, ""},
+	}
+	for _, test := range tests {
+		if got := suggest(test.text, test.alts); got != test.want {
+			t.Errorf("suggest(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/guilyx/go-pddl/src/models"
 )
 
@@ -14,42 +16,82 @@ func (p *Parser) ParseDomain() (*models.Domain, *models.PddlError) {
 	if err != nil {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
 	}
-	if tk.Text != "domain" {
+	if !strings.EqualFold(tk.Text, "domain") {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: input file isn't a valid domain.")
 	}
 	name, err := p.DomainToolbox.parseDomainName()
 	if err != nil {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
 	}
+	err = p.DomainToolbox.skipUnknown(domainSections)
+	if err != nil {
+		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
+	}
 	reqs, err := p.DomainToolbox.parseRequirements()
 	if err != nil {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
 	}
+	err = p.DomainToolbox.skipUnknown(domainSections)
+	if err != nil {
+		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
+	}
 	typs, err := p.DomainToolbox.parseTypesDefinition()
 	if err != nil {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
 	}
+	err = p.DomainToolbox.skipUnknown(domainSections)
+	if err != nil {
+		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
+	}
 	csts, err := p.DomainToolbox.parseConstantsDefinition()
 	if err != nil {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
 	}
+	err = p.DomainToolbox.skipUnknown(domainSections)
+	if err != nil {
+		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
+	}
 	preds, err := p.DomainToolbox.parsePredicatesDefinition()
 	if err != nil {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
 	}
+	err = p.DomainToolbox.skipUnknown(domainSections)
+	if err != nil {
+		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
+	}
 	funcs := p.DomainToolbox.parseFuncsDef()
 	if err != nil {
 		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
 	}
-	acts := p.DomainToolbox.parseActionsDef()
 	d := &models.Domain{
 		Name:         name,
-		Actions:      acts,
 		Constants:    csts,
 		Functions:    funcs,
 		Predicates:   preds,
 		Requirements: reqs,
 		Types:        typs,
 	}
+	err = p.DomainToolbox.parseStructuresDef(d)
+	if err != nil {
+		return nil, p.DomainToolbox.NewPddlError("Failed to parse domain: %v", err.Error)
+	}
+	err = checkProbabilities(d)
+	if err != nil {
+		return nil, err
+	}
+	_, err = models.NewTypeHierarchy(d, nil)
+	if err != nil {
+		return nil, err
+	}
+	sc := newScope(d, nil)
+	err = sc.checkFunctions(d.Functions)
+	if err != nil {
+		return nil, err
+	}
+	err = sc.checkEffects(d)
+	if err != nil {
+		return nil, err
+	}
+	p.domain = d
 	return d, nil
 }
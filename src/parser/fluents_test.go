@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+)
+
+// fluentsDomain returns a domain with object fluents, declaring the
+// requirements and with the given effect for drive.
+func fluentsDomain(reqs string, effect string) string {
+	return `(define (domain trucks)
+  (:requirements :strips :typing :equality ` + reqs + `)
+  (:types truck location)
+  (:predicates (road ?from ?to - location) (visited ?l - location))
+  (:functions (loc ?t - truck) - location (dest ?t - truck) - location (fuel ?t - truck))
+  (:action drive
+    :parameters (?t - truck ?to - location)
+    :precondition (and (road (loc ?t) ?to) (not (= (loc ?t) ?to)))
+    :effect ` + effect + `))
+`
+}
+
+const fluentsEffect = `(and (assign (loc ?t) ?to) (visited ?to) (decrease (fuel ?t) 1))`
+
+// fluentsProblem returns a problem initializing the fluents with the
+// given extra init elements.
+func fluentsProblem(init string) string {
+	return `(define (problem trucks) (:domain trucks)
+  (:objects t1 t2 - truck l1 l2 - location)
+  (:init (road l1 l2) (= (loc t1) l1) (= (loc t2) (loc t1)) (= (dest t1) l2) (= (fuel t1) 5) ` + init + `)
+  (:goal (= (loc t1) (dest t1))))
+`
+}
+
+func TestObjectFluents(t *testing.T) {
+	d, pb, err := ParseTexts(&config.Config{}, fluentsDomain(":object-fluents :numeric-fluents", fluentsEffect), fluentsProblem(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Functions[0].IsObject() || d.Functions[2].IsObject() {
+		t.Errorf("got object fluents %t %t", d.Functions[0].IsObject(), d.Functions[2].IsObject())
+	}
+	if !strings.Contains(d.ToString(), "(loc ?t - truck) - location") {
+		t.Errorf("the type of loc isn't printed:\n%s", d.ToString())
+	}
+	again, againPb, err := ParseTexts(&config.Config{}, d.ToString(), pb.ToString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ToString() != d.ToString() || againPb.ToString() != pb.ToString() {
+		t.Errorf("the printed task parses differently")
+	}
+	s, err := pb.InitialState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// t2 is initialized to the location of t1.
+	if v, ok := s.ObjectFluent("loc", []string{"t2"}); !ok || v != "l1" {
+		t.Errorf("got (loc t2) = %q, %t, want l1", v, ok)
+	}
+}
+
+func TestObjectFluentErrors(t *testing.T) {
+	tests := []struct {
+		reqs, effect, init string
+		want               string
+	}{
+		{":numeric-fluents", fluentsEffect, "", "object fluents require :object-fluents"},
+		{":object-fluents :numeric-fluents", `(assign (loc ?t) 3)`, "", "loc takes an object"},
+		{":object-fluents :numeric-fluents", `(assign (fuel ?t) ?to)`, "", "fuel takes a number"},
+		{":object-fluents :numeric-fluents", fluentsEffect, "(= (fuel t2) l1)", "fuel takes a number"},
+		{":object-fluents :numeric-fluents", fluentsEffect, "(= (dest t2) 4)", "dest takes an object"},
+	}
+	for _, test := range tests {
+		_, _, err := ParseTexts(&config.Config{}, fluentsDomain(test.reqs, test.effect), fluentsProblem(test.init))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s %s %s: got error %v, want %q", test.reqs, test.effect, test.init, err, test.want)
+		}
+	}
+	// :fluents stands for :object-fluents.
+	if _, _, err := ParseTexts(&config.Config{}, fluentsDomain(":fluents", fluentsEffect), fluentsProblem("")); err != nil {
+		t.Errorf("under :fluents: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/guilyx/go-pddl/src/lexer"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+func (p *ParserToolbox) parseTaskDef() (*models.Task, *models.PddlError) {
+	err := p.Expects("(", ":task")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse task: %v", err.Error)
+	}
+	t := &models.Task{}
+	t.Name, err = p.parseName(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse task: %v", err.Error)
+	}
+	defer p.within("task '%s'", t.Name.Name)()
+	t.Params = p.parseActionParams()
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse task %s: %v", t.Name.Name, err.Error)
+	}
+	return t, nil
+}
+
+func (p *ParserToolbox) parseMethodDef() (*models.Method, *models.PddlError) {
+	err := p.Expects("(", ":method")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method: %v", err.Error)
+	}
+	m := &models.Method{}
+	m.Name, err = p.parseName(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method: %v", err.Error)
+	}
+	defer p.within("method '%s'", m.Name.Name)()
+	m.Params = p.parseActionParams()
+	err = p.Expects(":task", "(")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method %s: %v", m.Name.Name, err.Error)
+	}
+	m.Task, err = p.parseName(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method %s: %v", m.Name.Name, err.Error)
+	}
+	m.TaskTerms, err = p.parseTerms()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method %s: %v", m.Name.Name, err.Error)
+	}
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method %s: %v", m.Name.Name, err.Error)
+	}
+	if ok, _ := p.Accepts(":precondition"); ok {
+		leave := p.within(":precondition")
+		if ok, _ := p.Accepts("(", ")"); !ok {
+			m.Precondition = parsePreGd(p)
+		}
+		leave()
+	}
+	m.Network, err = p.parseTaskNetwork()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method %s: %v", m.Name.Name, err.Error)
+	}
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse method %s: %v", m.Name.Name, err.Error)
+	}
+	return m, nil
+}
+
+// parseTaskNetwork parses the subtasks, ordering and constraints of
+// a method or of the initial task network. Only empty constraints
+// are supported.
+func (p *ParserToolbox) parseTaskNetwork() (*models.TaskNetwork, *models.PddlError) {
+	tn := &models.TaskNetwork{}
+	tk, err := p.Peek()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse task network: %v", err.Error)
+	}
+	switch strings.ToLower(tk.Text) {
+	case ":ordered-subtasks", ":ordered-tasks":
+		tn.Ordered = true
+		fallthrough
+	case ":subtasks", ":tasks":
+		p.Junk(1)
+		err = p.parseConjunction(func() *models.PddlError {
+			st, err := p.parseSubtask()
+			if err != nil {
+				return err
+			}
+			tn.Subtasks = append(tn.Subtasks, st)
+			return nil
+		})
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse subtasks: %v", err.Error)
+		}
+	}
+	if ok, _ := p.Accepts(":ordering"); ok {
+		err = p.parseConjunction(func() *models.PddlError {
+			o, err := p.parseOrdering()
+			if err != nil {
+				return err
+			}
+			tn.Orderings = append(tn.Orderings, o)
+			return nil
+		})
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse ordering: %v", err.Error)
+		}
+	}
+	if ok, _ := p.Accepts(":constraints"); ok {
+		err = p.Expects("(", ")")
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse task network: only empty constraints are supported")
+		}
+	}
+	return tn, nil
+}
+
+// parseConjunction calls parseEl on every element of "()", "(and ...)"
+// or a single element.
+func (p *ParserToolbox) parseConjunction(parseEl func() *models.PddlError) *models.PddlError {
+	if ok, _ := p.Accepts("(", ")"); ok {
+		return nil
+	}
+	if ok, _ := p.Accepts("(", "and"); !ok {
+		return parseEl()
+	}
+	tk, err := p.Peek()
+	for err == nil && tk.Type == lexer.TOKEN_OPEN {
+		if err = parseEl(); err != nil {
+			return err
+		}
+		tk, err = p.Peek()
+	}
+	if err != nil {
+		return err
+	}
+	return p.Expects(")")
+}
+
+// parseSubtask parses (task0 (load ?v ?l ?p)) or the anonymous
+// (load ?v ?l ?p).
+func (p *ParserToolbox) parseSubtask() (*models.Subtask, *models.PddlError) {
+	loc, _ := p.Locate()
+	err := p.Expects("(")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse subtask: %v", err.Error)
+	}
+	st := &models.Subtask{
+		Node: &models.Node{
+			Location: loc,
+		},
+	}
+	n, err := p.parseName(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse subtask: %v", err.Error)
+	}
+	if ok, _ := p.Accepts("("); ok {
+		st.Id = n
+		n, err = p.parseName(lexer.TOKEN_NAME)
+		if err != nil {
+			return nil, p.NewPddlError("Failed to parse subtask %s: %v", st.Id.Name, err.Error)
+		}
+		defer p.Expects(")")
+	}
+	st.Task = n
+	st.Terms, err = p.parseTerms()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse subtask %s: %v", n.Name, err.Error)
+	}
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse subtask %s: %v", n.Name, err.Error)
+	}
+	return st, nil
+}
+
+func (p *ParserToolbox) parseOrdering() (*models.Ordering, *models.PddlError) {
+	loc, _ := p.Locate()
+	err := p.Expects("(", "<")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse ordering: %v", err.Error)
+	}
+	o := &models.Ordering{
+		Node: &models.Node{
+			Location: loc,
+		},
+	}
+	o.First, err = p.parseName(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse ordering: %v", err.Error)
+	}
+	o.Second, err = p.parseName(lexer.TOKEN_NAME)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse ordering: %v", err.Error)
+	}
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse ordering: %v", err.Error)
+	}
+	return o, nil
+}
+
+// parseHtn parses the initial task network of a problem, when present.
+func (p *ParserToolbox) parseHtn(sc *scope) (*models.TaskNetwork, *models.PddlError) {
+	kind, err := p.PeekNth(2)
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse initial task network: %v", err.Error)
+	}
+	ok, err := p.Accepts("(", ":htn")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse initial task network: %v", err.Error)
+	}
+	if !ok {
+		return nil, nil
+	}
+	if !sc.has(":hierarchy") {
+		return nil, p.newTokenError(kind, fmt.Sprintf("Failed to parse initial task network: %s requires :hierarchy", kind.Text))
+	}
+	defer p.within(":htn")()
+	var params []*models.TypedEntry
+	tk, err := p.Peek()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse initial task network: %v", err.Error)
+	}
+	if strings.EqualFold(tk.Text, ":parameters") {
+		params = p.parseActionParams()
+	}
+	tn, err := p.parseTaskNetwork()
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse initial task network: %v", err.Error)
+	}
+	tn.Params = params
+	err = p.Expects(")")
+	if err != nil {
+		return nil, p.NewPddlError("Failed to parse initial task network: %v", err.Error)
+	}
+	return tn, nil
+}
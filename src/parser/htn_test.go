@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+)
+
+const htnDomain = `(define (domain delivery)
+  (:requirements :strips :typing :negative-preconditions :hierarchy)
+  (:types truck place)
+  (:predicates (at ?t - truck ?p - place) (road ?a ?b - place))
+  (:task deliver :parameters (?t - truck ?p - place))
+  (:task go :parameters (?t - truck ?p - place))
+  (:method direct
+    :parameters (?t - truck ?a ?b - place)
+    :task (go ?t ?b)
+    :precondition (and (at ?t ?a) (road ?a ?b))
+    :ordered-subtasks (drive ?t ?a ?b))
+  (:method via
+    :parameters (?t - truck ?a ?b - place)
+    :task (deliver ?t ?b)
+    :precondition (not (at ?t ?b))
+    :subtasks (and (t1 (go ?t ?a)) (t2 (go ?t ?b)))
+    :ordering (and (< t1 t2)))
+  (:action drive
+    :parameters (?t - truck ?a ?b - place)
+    :precondition (and (at ?t ?a) (road ?a ?b))
+    :effect (and (not (at ?t ?a)) (at ?t ?b))))
+`
+
+const htnProblem = `(define (problem delivery) (:domain delivery)
+  (:objects t - truck p1 p2 p3 - place)
+  (:htn :parameters (?x - truck) :ordered-subtasks (and (d1 (deliver ?x p3))))
+  (:init (at t p1) (road p1 p2) (road p2 p3)))
+`
+
+func TestParseHtn(t *testing.T) {
+	d, pb, err := ParseTexts(&config.Config{}, htnDomain, htnProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Tasks) != 2 || d.Tasks[0].Name.Name != "deliver" || len(d.Tasks[0].Params) != 2 {
+		t.Errorf("got tasks %d", len(d.Tasks))
+	}
+	if len(d.Methods) != 2 {
+		t.Fatalf("got %d methods, want 2", len(d.Methods))
+	}
+	direct, via := d.Methods[0], d.Methods[1]
+	if direct.Task.Name != "go" || len(direct.TaskTerms) != 2 || !direct.Network.Ordered ||
+		len(direct.Network.Subtasks) != 1 || direct.Network.Subtasks[0].Task.Name != "drive" {
+		t.Errorf("got method %s", direct.Name.Name)
+	}
+	if via.Network.Ordered || len(via.Network.Subtasks) != 2 || via.Network.Subtasks[1].Id.Name != "t2" {
+		t.Errorf("got method %s", via.Name.Name)
+	}
+	if len(via.Network.Orderings) != 1 || via.Network.Orderings[0].First.Name != "t1" || via.Network.Orderings[0].Second.Name != "t2" {
+		t.Errorf("got orderings %d", len(via.Network.Orderings))
+	}
+	if pb.Htn == nil || len(pb.Htn.Params) != 1 || !pb.Htn.Ordered || pb.Htn.Subtasks[0].Task.Name != "deliver" {
+		t.Fatalf("got initial task network %v", pb.Htn)
+	}
+	if pb.Goal != nil {
+		t.Errorf("got goal %s, the problem has none", pb.Goal.ToString(""))
+	}
+	again, againPb, err := ParseTexts(&config.Config{}, d.ToString(), pb.ToString())
+	if err != nil {
+		t.Fatalf("parsing the printed task: %v\n%s\n%s", err, d.ToString(), pb.ToString())
+	}
+	if again.ToString() != d.ToString() || againPb.ToString() != pb.ToString() {
+		t.Errorf("the printed task parses differently")
+	}
+	for _, want := range []string{":ordered-subtasks", ":ordering", "(t1 (go ?t ?a))", "(< t1 t2)"} {
+		if !strings.Contains(d.ToString(), want) {
+			t.Errorf("%s isn't printed:\n%s", want, d.ToString())
+		}
+	}
+}
+
+func TestParseHtnRequirement(t *testing.T) {
+	domain := strings.Replace(htnDomain, " :hierarchy", "", 1)
+	_, _, err := ParseTexts(&config.Config{}, domain, htnProblem)
+	if err == nil || !strings.Contains(err.Error(), ":task requires :hierarchy") {
+		t.Errorf("got error %v, want :task requires :hierarchy", err)
+	}
+}
@@ -5,12 +5,18 @@ import (
 
 	"github.com/guilyx/go-pddl/src/common"
 	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/cst"
 	"github.com/guilyx/go-pddl/src/lexer"
+	"github.com/guilyx/go-pddl/src/models"
 )
 
 type Parser struct {
 	DomainToolbox  *ParserToolbox
 	ProblemToolbox *ParserToolbox
+
+	// domain is the domain parsed last, whose requirements and
+	// functions apply to the problems parsed next.
+	domain *models.Domain
 }
 
 func NewParser() *Parser {
@@ -54,3 +60,101 @@ func (p *Parser) RegisterProblem(config *config.Config) error {
 	}
 	return nil
 }
+
+// ParseFiles parses a domain file and a problem file with the settings
+// of the configuration.
+func ParseFiles(conf *config.Config, domain string, problem string) (*models.Domain, *models.Problem, error) {
+	c := *conf
+	c.Domain, c.Problem = domain, problem
+	p := NewParser()
+	err := p.RegisterDomain(&c)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = p.RegisterProblem(&c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.parse()
+}
+
+// ParseTexts parses the text of a domain and of a problem, named
+// after the files of the configuration, or domain and problem.
+func ParseTexts(conf *config.Config, domain string, problem string) (*models.Domain, *models.Problem, error) {
+	p := NewParser()
+	var err error
+	p.DomainToolbox, err = newTextToolbox(conf, conf.Domain, "domain", domain)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.ProblemToolbox, err = newTextToolbox(conf, conf.Problem, "problem", problem)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.parse()
+}
+
+// newTextToolbox returns a toolbox over the text, named name, or else
+// what it is.
+func newTextToolbox(conf *config.Config, name string, what string, text string) (*ParserToolbox, error) {
+	if name == "" {
+		name = what
+	}
+	l, err := lexer.NewLexer(name, text)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %v", what, err)
+	}
+	tb, err := NewParserToolbox(conf, l)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %v", what, err)
+	}
+	return tb, nil
+}
+
+// parse parses the registered domain, then the registered problem.
+func (p *Parser) parse() (*models.Domain, *models.Problem, error) {
+	d, errPddl := p.ParseDomain()
+	if errPddl != nil {
+		return nil, nil, errPddl.ToError()
+	}
+	pb, errPddl := p.ParseProblem()
+	if errPddl != nil {
+		return nil, nil, errPddl.ToError()
+	}
+	return d, pb, nil
+}
+
+// ParseFactor parses the domain and problem files of one agent of a
+// factored MA-PDDL task.
+func ParseFactor(conf *config.Config, agent string, domain string, problem string) (*models.Factor, error) {
+	d, pb, err := ParseFiles(conf, domain, problem)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse agent %s: %v", agent, err)
+	}
+	return &models.Factor{
+		Agent:   agent,
+		Domain:  d,
+		Problem: pb,
+	}, nil
+}
+
+// DomainTree returns the concrete syntax tree of the registered
+// domain. The locations of the parsed domain map to its nodes.
+func (p *Parser) DomainTree() (*cst.Tree, error) {
+	if p == nil || p.DomainToolbox == nil {
+		return nil, fmt.Errorf("Failed to get domain tree: no domain registered")
+	}
+	return p.DomainToolbox.Tree, nil
+}
+
+// ProblemTree returns the concrete syntax tree of the registered
+// problem, which a streamed problem doesn't have.
+func (p *Parser) ProblemTree() (*cst.Tree, error) {
+	if p == nil || p.ProblemToolbox == nil {
+		return nil, fmt.Errorf("Failed to get problem tree: no problem registered")
+	}
+	if p.ProblemToolbox.Tree == nil {
+		return nil, fmt.Errorf("Failed to get problem tree: problem was streamed")
+	}
+	return p.ProblemToolbox.Tree, nil
+}
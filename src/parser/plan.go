@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/guilyx/go-pddl/src/common"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+var costComment = regexp.MustCompile(`^;\s*cost\s*=\s*(\S+)(\s*\((unit|general) cost\))?`)
+
+// ParsePlan reads an IPC plan file and binds its steps against the
+// domain actions and the problem objects.
+func (p *Parser) ParsePlan(path string, d *models.Domain, pb *models.Problem) (*models.Plan, *models.PddlError) {
+	text, err := common.LoadFile(path)
+	if err != nil {
+		return nil, &models.PddlError{
+			Location: &models.Location{
+				Path: path,
+			},
+			Error: fmt.Errorf("Failed to parse plan: %v", err),
+		}
+	}
+	return ParsePlanText(path, text, d, pb)
+}
+
+// ParsePlanText parses plan text where every non-comment line is
+// either "(action arg...)" or "time: (action arg...) [duration]".
+func ParsePlanText(name string, text string, d *models.Domain, pb *models.Problem) (*models.Plan, *models.PddlError) {
+	if d == nil || pb == nil {
+		return nil, &models.PddlError{
+			Error: fmt.Errorf("Failed to parse plan: domain or problem is nil"),
+		}
+	}
+	acts := map[string]*models.Action{}
+	actNames := map[string]string{}
+	for _, a := range d.Actions {
+		acts[a.Name.Name] = a
+		declare(actNames, a.Name.Name)
+	}
+	objs := map[string]string{}
+	for _, o := range d.Constants {
+		declare(objs, o.Name.Name)
+	}
+	for _, o := range pb.Objects {
+		declare(objs, o.Name.Name)
+	}
+	plan := &models.Plan{}
+	for i, line := range strings.Split(text, "\n") {
+		loc := &models.Location{
+			Path: name,
+			Line: i + 1,
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ";") {
+			if m := costComment.FindStringSubmatch(line); m != nil {
+				plan.Cost = m[1]
+				plan.UnitCost = m[3] == "unit"
+			}
+			continue
+		}
+		if c := strings.Index(line, ";"); c >= 0 {
+			line = strings.TrimSpace(line[:c])
+		}
+		if line == "" {
+			continue
+		}
+		st, err := parsePlanStep(line, loc)
+		if err != nil {
+			return nil, &models.PddlError{
+				Location: loc,
+				Error:    fmt.Errorf("Failed to parse plan: %v", err),
+			}
+		}
+		if !rename(st.Action, actNames) {
+			return nil, &models.PddlError{
+				Location: loc,
+				Error:    fmt.Errorf("Failed to parse plan: unknown action [%s]", st.Action.Name),
+			}
+		}
+		act := acts[st.Action.Name]
+		if len(act.Params) != len(st.Terms) {
+			return nil, &models.PddlError{
+				Location: loc,
+				Error: fmt.Errorf("Failed to parse plan: action [%s] expects %d arguments, got %d",
+					st.Action.Name, len(act.Params), len(st.Terms)),
+			}
+		}
+		for _, t := range st.Terms {
+			if !rename(t.Name, objs) {
+				return nil, &models.PddlError{
+					Location: loc,
+					Error:    fmt.Errorf("Failed to parse plan: unknown object [%s] in [%s]", t.Name.Name, st.ToString()),
+				}
+			}
+		}
+		st.Definition = act
+		plan.Steps = append(plan.Steps, st)
+	}
+	return plan, nil
+}
+
+// declare adds a declared name to the names a plan may refer to, both
+// as is and lower-cased.
+func declare(names map[string]string, name string) {
+	if _, ok := names[strings.ToLower(name)]; !ok {
+		names[strings.ToLower(name)] = name
+	}
+	names[name] = name
+}
+
+// rename gives a name of a plan the declared name it matches, exactly
+// or else whatever its case, as planners often print upper-cased names.
+func rename(n *models.Name, names map[string]string) bool {
+	name, ok := names[n.Name]
+	if !ok {
+		name, ok = names[strings.ToLower(n.Name)]
+	}
+	if !ok {
+		return false
+	}
+	if name != n.Name {
+		n.Original = n.Name
+		n.Name = name
+	}
+	return true
+}
+
+func parsePlanStep(line string, loc *models.Location) (*models.PlanStep, error) {
+	st := &models.PlanStep{
+		Node: &models.Node{
+			Location: loc,
+		},
+	}
+	open := strings.Index(line, "(")
+	if open < 0 {
+		return nil, fmt.Errorf("expected [(] in [%s]", line)
+	}
+	if open > 0 {
+		t := strings.TrimSpace(line[:open])
+		if !strings.HasSuffix(t, ":") {
+			return nil, fmt.Errorf("expected [time:] before action, got [%s]", t)
+		}
+		t = strings.TrimSpace(strings.TrimSuffix(t, ":"))
+		if _, err := strconv.ParseFloat(t, 64); err != nil {
+			return nil, fmt.Errorf("invalid time [%s]", t)
+		}
+		st.IsTimed = true
+		st.Time = t
+	}
+	close := strings.Index(line, ")")
+	if close < open {
+		return nil, fmt.Errorf("expected [)] in [%s]", line)
+	}
+	fields := strings.Fields(line[open+1 : close])
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing action name in [%s]", line)
+	}
+	st.Action = &models.Name{
+		Name:     fields[0],
+		Location: loc,
+	}
+	for _, f := range fields[1:] {
+		st.Terms = append(st.Terms, &models.Term{
+			Name: &models.Name{
+				Name:     f,
+				Location: loc,
+			},
+		})
+	}
+	rest := strings.TrimSpace(line[close+1:])
+	if rest == "" {
+		return st, nil
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+		return nil, fmt.Errorf("unexpected [%s] after action", rest)
+	}
+	dur := strings.TrimSpace(rest[1 : len(rest)-1])
+	if _, err := strconv.ParseFloat(dur, 64); err != nil {
+		return nil, fmt.Errorf("invalid duration [%s]", dur)
+	}
+	st.Duration = dur
+	return st, nil
+}
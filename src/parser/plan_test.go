@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+const planDomain = `(define (domain blocks)
+  (:requirements :strips :typing)
+  (:types block)
+  (:predicates (on ?x - block ?y - block) (ontable ?x - block)
+               (clear ?x - block) (handempty) (holding ?x - block))
+  (:action pick-up
+    :parameters (?x - block)
+    :precondition (and (clear ?x) (ontable ?x) (handempty))
+    :effect (and (not (ontable ?x)) (not (clear ?x)) (not (handempty)) (holding ?x)))
+  (:action stack
+    :parameters (?x - block ?y - block)
+    :precondition (and (holding ?x) (clear ?y))
+    :effect (and (not (holding ?x)) (not (clear ?y)) (clear ?x) (handempty) (on ?x ?y))))
+`
+
+const planProblem = `(define (problem two) (:domain blocks)
+  (:objects b0 b1 - block)
+  (:init (handempty) (ontable b0) (ontable b1) (clear b0) (clear b1))
+  (:goal (on b0 b1)))
+`
+
+func parsePlanTask(t *testing.T) (*models.Domain, *models.Problem) {
+	d, pb, err := ParseTexts(&config.Config{}, planDomain, planProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, pb
+}
+
+func TestParsePlanText(t *testing.T) {
+	d, pb := parsePlanTask(t)
+	text := `; a plan
+(pick-up b0)
+(STACK B0 B1) ; upper-cased, as some planners print
+; cost = 2 (unit cost)
+`
+	plan, perr := ParsePlanText("plan", text, d, pb)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	if len(plan.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(plan.Steps))
+	}
+	st := plan.Steps[1]
+	if st.Action.Name != "stack" || st.Action.Original != "STACK" || st.Definition != d.Actions[1] {
+		t.Errorf("got step %s bound to %v", st.ToString(), st.Definition)
+	}
+	if plan.Cost != "2" || !plan.UnitCost || plan.IsTemporal() {
+		t.Errorf("got cost %q, unit %t, temporal %t", plan.Cost, plan.UnitCost, plan.IsTemporal())
+	}
+	want := "(pick-up b0)\n(stack b0 b1)\n; cost = 2 (unit cost)\n"
+	if plan.ToString() != want {
+		t.Errorf("got plan %q, want %q", plan.ToString(), want)
+	}
+	again, perr := ParsePlanText("plan", plan.ToString(), d, pb)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	if again.ToString() != want {
+		t.Errorf("got plan %q after a round trip, want %q", again.ToString(), want)
+	}
+}
+
+func TestParseTemporalPlan(t *testing.T) {
+	d, pb := parsePlanTask(t)
+	text := "0.000: (pick-up b0) [1.000]\n1.001: (stack b0 b1) [1.000]\n"
+	plan, perr := ParsePlanText("plan", text, d, pb)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	if !plan.IsTemporal() || plan.Steps[1].Time != "1.001" || plan.Steps[1].Duration != "1.000" {
+		t.Errorf("got plan %q", plan.ToString())
+	}
+	if plan.ToString() != text {
+		t.Errorf("got plan %q, want %q", plan.ToString(), text)
+	}
+}
+
+func TestParsePlanErrors(t *testing.T) {
+	d, pb := parsePlanTask(t)
+	tests := []struct {
+		text string
+		line int
+		want string
+	}{
+		{"(pick-up b0)\n(fly b0)\n", 2, "unknown action [fly]"},
+		{"(pick-up b0 b1)\n", 1, "action [pick-up] expects 1 arguments, got 2"},
+		{"(pick-up b9)\n", 1, "unknown object [b9]"},
+		{"pick-up b0\n", 1, "expected [(]"},
+		{"x: (pick-up b0)\n", 1, "invalid time [x]"},
+		{"(pick-up b0) [long]\n", 1, "invalid duration [long]"},
+	}
+	for _, test := range tests {
+		_, perr := ParsePlanText("plan", test.text, d, pb)
+		if perr == nil {
+			t.Errorf("%q: got no error", test.text)
+			continue
+		}
+		if perr.Location.Line != test.line || !strings.Contains(perr.Error.Error(), test.want) {
+			t.Errorf("%q: got %q at line %d, want %q at line %d",
+				test.text, perr.Error, perr.Location.Line, test.want, test.line)
+		}
+	}
+}
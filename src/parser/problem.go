@@ -1,7 +1,13 @@
 package parser
 
-import "github.com/guilyx/go-pddl/src/models"
+import (
+	"strings"
 
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// ParseProblem parses the registered problem, in the scope of the
+// domain parsed last, if any.
 func (p *Parser) ParseProblem() (*models.Problem, *models.PddlError) {
 	p.ProblemToolbox.Expects("(", "define")
 	defer p.ProblemToolbox.Expects(")")
@@ -9,25 +15,73 @@ func (p *Parser) ParseProblem() (*models.Problem, *models.PddlError) {
 	if err != nil {
 		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
 	}
-	if tk.Text != "problem" {
+	if !strings.EqualFold(tk.Text, "problem") {
 		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: input file isn't a valid problem.")
 	}
 	name := p.ProblemToolbox.parseProbName()
+	err = p.ProblemToolbox.skipUnknown(problemSections)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
 	dom := p.ProblemToolbox.parseProbDomain()
+	err = p.ProblemToolbox.skipUnknown(problemSections)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
 	reqs, err := p.ProblemToolbox.parseRequirements()
 	if err != nil {
 		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
 	}
+	err = p.ProblemToolbox.skipUnknown(problemSections)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
 	obj := p.ProblemToolbox.parseObjsDecl()
-	init := p.ProblemToolbox.parseInit()
+	err = p.ProblemToolbox.skipUnknown(problemSections)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
+	sc := newScope(p.domain, reqs)
+	htn, err := p.ProblemToolbox.parseHtn(sc)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
+	err = p.ProblemToolbox.skipUnknown(problemSections)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
+	init, err := p.ProblemToolbox.parseInit()
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
+	for _, el := range init {
+		err = sc.checkInit(el)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = p.ProblemToolbox.skipUnknown(problemSections)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
 	goal := p.ProblemToolbox.parseGoal()
+	err = p.ProblemToolbox.skipUnknown(problemSections)
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
+	metric, err := p.ProblemToolbox.parseMetric()
+	if err != nil {
+		return nil, p.ProblemToolbox.NewPddlError("Failed to parse problem: %v", err.Error)
+	}
 	pb := &models.Problem {
 		Domain: dom,
 		Goal: goal,
 		InitialConditions: init,
+		Metric: metric,
 		Name: name,
 		Objects: obj,
 		Requirements: reqs,
+		Htn: htn,
 	}
 	return pb, nil
 }
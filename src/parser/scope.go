@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// scope is what a domain, or a problem of a domain, can use: the
+// requirements and the functions declared. Without the domain, the
+// requirements aren't known, and aren't checked.
+type scope struct {
+	reqs  map[string]bool
+	funcs map[string]*models.Function
+	known bool
+}
+
+// newScope returns the scope of the domain, which may be nil, extended
+// with the requirements of a problem.
+func newScope(d *models.Domain, reqs []*models.Name) *scope {
+	s := &scope{
+		reqs:  map[string]bool{},
+		funcs: map[string]*models.Function{},
+	}
+	if d != nil {
+		s.known = true
+		for _, r := range d.Requirements {
+			s.reqs[r.Name] = true
+		}
+		for _, f := range d.Functions {
+			s.funcs[f.Name.Name] = f
+		}
+	}
+	for _, r := range reqs {
+		s.reqs[r.Name] = true
+	}
+	return s
+}
+
+// has tells whether one of the requirements is declared, or whether
+// requirements aren't known.
+func (s *scope) has(reqs ...string) bool {
+	if !s.known {
+		return true
+	}
+	for _, r := range reqs {
+		if s.reqs[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFunctions checks that object fluents are declared under
+// :object-fluents or :fluents.
+func (s *scope) checkFunctions(fs []*models.Function) *models.PddlError {
+	for _, f := range fs {
+		if f.IsObject() && !s.has(":object-fluents", ":fluents") {
+			return &models.PddlError{
+				Location: f.Name.Location,
+				Error:    fmt.Errorf("Failed to parse function %s: object fluents require :object-fluents", f.Name.Text()),
+			}
+		}
+	}
+	return nil
+}
+
+// isObject tells whether the value assigned by the node is an object,
+// and whether that is known.
+func (s *scope) isObject(n *models.AssignNode) (bool, bool) {
+	switch {
+	case n.Term != nil:
+		return true, true
+	case n.FunctionInit != nil:
+		f, ok := s.funcs[n.FunctionInit.Name.Name]
+		if !ok {
+			return false, false
+		}
+		return f.IsObject(), true
+	}
+	return false, true
+}
+
+// checkAssign checks that an assignment assigns objects to object
+// fluents only, under :object-fluents, and numbers to numeric ones.
+func (s *scope) checkAssign(n *models.AssignNode) *models.PddlError {
+	object, known := s.isObject(n)
+	if object && !s.has(":object-fluents", ":fluents") {
+		return &models.PddlError{
+			Location: n.AssignedTo.Name.Location,
+			Error:    fmt.Errorf("Failed to check %s: assigning objects requires :object-fluents", n.ToString("")),
+		}
+	}
+	f, ok := s.funcs[n.AssignedTo.Name.Name]
+	if !ok || !known || f.IsObject() == object {
+		return nil
+	}
+	want := "a number"
+	if f.IsObject() {
+		want = "an object"
+	}
+	return &models.PddlError{
+		Location: n.AssignedTo.Name.Location,
+		Error:    fmt.Errorf("Failed to check %s: %s takes %s", n.ToString(""), f.Name.Text(), want),
+	}
+}
+
+// checkEffects checks the assignments in the effects of the domain.
+func (s *scope) checkEffects(d *models.Domain) *models.PddlError {
+	for _, a := range d.Structures() {
+		var perr *models.PddlError
+		models.Inspect(a.Effect, func(f models.Formula) bool {
+			if n, ok := f.(*models.AssignNode); ok && perr == nil {
+				perr = s.checkAssign(n)
+			}
+			return perr == nil
+		})
+		if perr != nil {
+			return perr
+		}
+	}
+	return nil
+}
+
+// checkInit checks an element of :init, which is timed under
+// :timed-initial-literals only.
+func (s *scope) checkInit(f models.Formula) *models.PddlError {
+	switch n := f.(type) {
+	case *models.AssignNode:
+		return s.checkAssign(n)
+	case *models.TimedInitNode:
+		if !s.has(":timed-initial-literals") {
+			return &models.PddlError{
+				Location: n.Node.Location,
+				Error:    fmt.Errorf("Failed to check %s: timed initial literals require :timed-initial-literals", n.ToString("")),
+			}
+		}
+		return s.checkInit(n.UnaryNode.Formula)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/lexer"
+)
+
+const sectionsDomain = `(define (domain sections)
+  (:requirements :strips)
+  (:predicates (p) (q))
+  (:derived (q) (p))
+  (:my-extension (foo (bar)) baz)
+  (:action a
+    :parameters ()
+    :precondition (p)
+    :effect (q)))
+`
+
+const sectionsProblem = `(define (problem sections) (:domain sections)
+  (:init (p))
+  (:goal (q))
+  (:constraints (always (p))))
+`
+
+func TestSkippedSections(t *testing.T) {
+	p := NewParser()
+	var err error
+	p.DomainToolbox, err = newTextToolbox(&config.Config{}, "", "domain", sectionsDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ProblemToolbox, err = newTextToolbox(&config.Config{}, "", "problem", sectionsProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, pb, err := p.parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Actions) != 1 || pb.Goal == nil {
+		t.Errorf("the sections after the skipped ones weren't parsed")
+	}
+	skipped := []string{}
+	for _, tb := range []*ParserToolbox{p.DomainToolbox, p.ProblemToolbox} {
+		for _, s := range tb.Skipped {
+			skipped = append(skipped, fmt.Sprintf("%s:%d", s.Name, s.Location.Line))
+		}
+	}
+	if got := strings.Join(skipped, " "); got != ":derived:4 :my-extension:5 :constraints:4" {
+		t.Errorf("got skipped sections %s", got)
+	}
+	typo := strings.Replace(sectionsDomain, ":derived", ":predicats", 1)
+	_, _, err = ParseTexts(&config.Config{}, typo, sectionsProblem)
+	if err == nil || !strings.Contains(err.Error(), "did you mean :predicates?") {
+		t.Errorf("got error %v for a misspelled section", err)
+	}
+}
+
+func TestDeepLookahead(t *testing.T) {
+	const depth = 500
+	params := []string{}
+	for i := 0; i < depth; i++ {
+		params = append(params, fmt.Sprintf("?x%d", i))
+	}
+	pre := "(p ?x0)"
+	for i := 0; i < depth; i++ {
+		pre = "(and " + pre + ")"
+	}
+	domain := `(define (domain deep)
+  (:requirements :strips)
+  (:predicates (p ?x))
+  (:action a
+    :parameters (` + strings.Join(params, " ") + `)
+    :precondition ` + pre + `
+    :effect (p ?x1)))
+`
+	d, _, err := ParseTexts(&config.Config{}, domain, `(define (problem deep) (:domain deep) (:init) (:goal (p o)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Actions[0].Params) != depth {
+		t.Errorf("got %d parameters, want %d", len(d.Actions[0].Params), depth)
+	}
+	tb, err := newTextToolbox(&config.Config{}, "", "domain", domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Past the end of the file is the end of file token.
+	tk, perr := tb.PeekNth(100000)
+	if perr != nil || tk.Type != lexer.TOKEN_EOF {
+		t.Errorf("got token %v, error %v", tk, perr)
+	}
+}
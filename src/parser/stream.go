@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/cst"
+	"github.com/guilyx/go-pddl/src/lexer"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// StreamProblem parses the problem file of the configuration as it is
+// read, one section at a time, so that very large problems don't have
+// to fit in memory. The elements of :init are parsed one at a time and
+// handed to consume instead of being kept in the InitialConditions of
+// the problem, unless consume is nil. The problem has no concrete
+// syntax tree. As with ParseProblem, the domain parsed last, if any,
+// is in scope.
+func (p *Parser) StreamProblem(config *config.Config, consume func(models.Formula) error) (*models.Problem, *models.PddlError) {
+	if p == nil || config == nil {
+		return nil, &models.PddlError{
+			Error: fmt.Errorf("Failed to stream problem: parser or config is nil"),
+		}
+	}
+	f, err := os.Open(config.Problem)
+	if err != nil {
+		return nil, &models.PddlError{
+			Location: &models.Location{
+				Path: config.Problem,
+			},
+			Error: fmt.Errorf("Failed to stream problem: %v", err),
+		}
+	}
+	defer f.Close()
+	lx, err := lexer.NewReaderLexer(config.Problem, f)
+	if err != nil {
+		return nil, &models.PddlError{
+			Location: &models.Location{
+				Path: config.Problem,
+			},
+			Error: fmt.Errorf("Failed to stream problem: %v", err),
+		}
+	}
+	p.ProblemToolbox = &ParserToolbox{
+		Configuration: config,
+		Lexer:         lx,
+	}
+	return p.ProblemToolbox.streamProblem(cst.NewStream(lx), p.domain, consume)
+}
+
+// streamError describes an error of the stream, at its location.
+func streamError(what string, err *models.PddlError) *models.PddlError {
+	return &models.PddlError{
+		Location: err.Location,
+		Error:    fmt.Errorf("Failed to stream %s: %v", what, err.Error),
+	}
+}
+
+// streamProblem parses the sections of a problem read by the stream,
+// each with a toolbox of its own.
+func (p *ParserToolbox) streamProblem(s *cst.Stream, d *models.Domain, consume func(models.Formula) error) (*models.Problem, *models.PddlError) {
+	define, err := s.Enter()
+	if err != nil {
+		return nil, streamError("problem", err)
+	}
+	if !strings.EqualFold(define.Text(), "define") {
+		return nil, p.newTokenError(define.Token, fmt.Sprintf("Failed to stream problem: expected [define], got [%s]", define.Text()))
+	}
+	pb := &models.Problem{}
+	for {
+		tk, err := s.Peek()
+		if err != nil {
+			return nil, streamError("problem", err)
+		}
+		if tk.Type == lexer.TOKEN_CLOSE || tk.Type == lexer.TOKEN_EOF {
+			break
+		}
+		sec, err := s.Enter()
+		if err != nil {
+			return nil, streamError("problem", err)
+		}
+		if strings.EqualFold(sec.Text(), ":init") {
+			errPddl := p.streamInit(s, pb, newScope(d, pb.Requirements), consume)
+			if errPddl != nil {
+				return nil, errPddl
+			}
+			continue
+		}
+		for {
+			c, err := s.Next()
+			if err != nil {
+				return nil, streamError("problem", err)
+			}
+			if c == nil {
+				break
+			}
+			c.Parent = sec
+			sec.Children = append(sec.Children, c)
+		}
+		errPddl := p.parseSection(sec, pb, newScope(d, pb.Requirements))
+		if errPddl != nil {
+			return nil, errPddl
+		}
+	}
+	_, err = s.Next()
+	if err != nil {
+		return nil, streamError("problem", err)
+	}
+	return pb, nil
+}
+
+// parseSection parses a section of a streamed problem, in the scope of
+// the domain.
+func (p *ParserToolbox) parseSection(sec *cst.Node, pb *models.Problem, sc *scope) *models.PddlError {
+	tb := newTreeToolbox(p.Configuration, p.Lexer, cst.NewTree(p.Lexer.Name, sec))
+	var err *models.PddlError
+	switch strings.ToLower(sec.Text()) {
+	case "problem":
+		pb.Name = tb.parseProbName()
+	case ":domain":
+		pb.Domain = tb.parseProbDomain()
+	case ":requirements":
+		pb.Requirements, err = tb.parseRequirements()
+	case ":objects":
+		pb.Objects = tb.parseObjsDecl()
+	case ":htn":
+		pb.Htn, err = tb.parseHtn(sc)
+	case ":goal":
+		pb.Goal = tb.parseGoal()
+	case ":metric":
+		pb.Metric, err = tb.parseMetric()
+	default:
+		err = tb.skipUnknown(problemSections)
+		p.Skipped = append(p.Skipped, tb.Skipped...)
+	}
+	if err != nil {
+		return err
+	}
+	return tb.expectsEnd()
+}
+
+// streamInit parses the elements of :init one at a time, up to the end
+// of the section, in the scope of the domain.
+func (p *ParserToolbox) streamInit(s *cst.Stream, pb *models.Problem, sc *scope, consume func(models.Formula) error) *models.PddlError {
+	for {
+		n, err := s.Next()
+		if err != nil {
+			return streamError("init", err)
+		}
+		if n == nil {
+			return nil
+		}
+		tb := newTreeToolbox(p.Configuration, p.Lexer, cst.NewTree(p.Lexer.Name, n))
+		if tk := tb.token(0); tk.Type != lexer.TOKEN_OPEN {
+			return tb.newTokenError(tk, "Failed to stream init: "+tb.unexpected(tk, "("))
+		}
+		el, errPddl := tb.parseInitEl()
+		if errPddl != nil {
+			return tb.NewPddlError("Failed to stream init: %v", errPddl.Error)
+		}
+		errPddl = tb.expectsEnd()
+		if errPddl != nil {
+			return errPddl
+		}
+		errPddl = sc.checkInit(el)
+		if errPddl != nil {
+			return errPddl
+		}
+		if consume == nil {
+			pb.InitialConditions = append(pb.InitialConditions, el)
+			continue
+		}
+		err2 := consume(el)
+		if err2 != nil {
+			return tb.newTokenError(n.Token, fmt.Sprintf("Failed to stream init: %v", err2))
+		}
+	}
+}
+
+// expectsEnd checks that all the tokens were parsed.
+func (p *ParserToolbox) expectsEnd() *models.PddlError {
+	tk, err := p.Next()
+	if err != nil {
+		return p.NewPddlError("Failed to parse: %v", err)
+	}
+	if tk.Type != lexer.TOKEN_EOF {
+		return p.newTokenError(tk, p.unexpected(tk, "end of list"))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/lexer"
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// streamTask parses the domain of the task, then streams its problem.
+func streamTask(t *testing.T, task Task, consume func(models.Formula) error) (*models.Problem, *models.PddlError) {
+	conf := &config.Config{
+		Identifiers: config.IdentifiersPreserve,
+		Domain:      task.Domain,
+		Problem:     task.Problem,
+	}
+	p := NewParser()
+	if err := p.RegisterDomain(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, perr := p.ParseDomain(); perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	return p.StreamProblem(conf, consume)
+}
+
+func TestStreamProblem(t *testing.T) {
+	task := writeTasks(t, 49)[48]
+	conf := &config.Config{
+		Identifiers: config.IdentifiersPreserve,
+	}
+	_, want, err := ParseFiles(conf, task.Domain, task.Problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	init := []string{}
+	pb, perr := streamTask(t, task, func(f models.Formula) error {
+		init = append(init, f.ToString(""))
+		return nil
+	})
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	if len(pb.InitialConditions) != 0 {
+		t.Errorf("got %d initial conditions kept while streaming", len(pb.InitialConditions))
+	}
+	if len(init) != len(want.InitialConditions) {
+		t.Fatalf("got %d elements of :init, want %d", len(init), len(want.InitialConditions))
+	}
+	for i, f := range want.InitialConditions {
+		if init[i] != f.ToString("") {
+			t.Errorf("element %d: got %s, want %s", i, init[i], f.ToString(""))
+		}
+	}
+	pb.InitialConditions = want.InitialConditions
+	if pb.ToString() != want.ToString() {
+		t.Errorf("got problem\n%s\nwant\n%s", pb.ToString(), want.ToString())
+	}
+
+	pb, perr = streamTask(t, task, nil)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	if pb.ToString() != want.ToString() {
+		t.Errorf("got problem\n%s\nwithout a callback, want\n%s", pb.ToString(), want.ToString())
+	}
+}
+
+func TestStreamProblemErrors(t *testing.T) {
+	task := writeTasks(t, 1)[0]
+	n := 0
+	_, perr := streamTask(t, task, func(f models.Formula) error {
+		n++
+		if n == 3 {
+			return fmt.Errorf("too many")
+		}
+		return nil
+	})
+	if perr == nil || !strings.Contains(perr.Error.Error(), "Failed to stream init: too many") {
+		t.Errorf("got error %v, want the callback error", perr)
+	}
+	if n != 3 {
+		t.Errorf("callback called %d times after its error", n)
+	}
+
+	tests := []struct {
+		problem string
+		want    string
+	}{
+		{"(define (problem p) (:domain blocks) (:init (on b0 b1) (ontable)", "Failed to stream"},
+		{"(define (problem p) (:domain blocks) (:init (handempty) x))", "Failed to stream init: Expected [(], got [x]"},
+		{"(define (problem p) (:domain blocks) (:init (at 1 (handempty))))", "require :timed-initial-literals"},
+	}
+	for _, test := range tests {
+		err := ioutil.WriteFile(task.Problem, []byte(test.problem), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, perr := streamTask(t, task, nil)
+		if perr == nil || !strings.Contains(perr.Error.Error(), test.want) {
+			t.Errorf("%q: got error %v, want %q", test.problem, perr, test.want)
+		}
+	}
+}
+
+func TestReaderLexer(t *testing.T) {
+	// Larger than what the reader lexer buffers at once.
+	text := testProblem(5000)
+	lx, err := lexer.NewLexer("problem", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rlx, err := lexer.NewReaderLexer("problem", iotest.OneByteReader(strings.NewReader(text)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rlx.Streamed() || lx.Streamed() {
+		t.Errorf("got streamed %t and %t", rlx.Streamed(), lx.Streamed())
+	}
+	for i := 0; ; i++ {
+		want, err := lx.ScanToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := rlx.ScanToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != *want {
+			t.Fatalf("token %d: got %+v, want %+v", i, *got, *want)
+		}
+		if want.Type == lexer.TOKEN_EOF {
+			break
+		}
+	}
+}
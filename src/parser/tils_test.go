@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+)
+
+// tilsDomain returns a domain declaring the requirements.
+func tilsDomain(reqs string) string {
+	return `(define (domain doors)
+  (:requirements :strips :numeric-fluents ` + reqs + `)
+  (:predicates (open ?d) (passed))
+  (:functions (level))
+  (:action pass
+    :parameters (?d)
+    :precondition (open ?d)
+    :effect (passed)))
+`
+}
+
+const tilsProblem = `(define (problem doors) (:domain doors)
+  (:objects d1 d2)
+  (:init (open d1) (= (level) 0)
+    (at 10.5 (not (open d1)))
+    (at 2 (open d2))
+    (at 5 (= (level) 3))
+    (at 2 (not (open d1))))
+  (:goal (passed)))
+`
+
+func TestTimedInitials(t *testing.T) {
+	d, pb, err := ParseTexts(&config.Config{}, tilsDomain(":timed-initial-literals"), tilsProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, til := range pb.TimedInitials() {
+		got = append(got, til.ToString(""))
+	}
+	// Ordered by time, simultaneous ones as declared.
+	want := "(at 2 (open d2)) (at 2 (not (open d1))) (at 5 (= (level) 3)) (at 10.5 (not (open d1)))"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got timed initials %s, want %s", strings.Join(got, " "), want)
+	}
+	if !strings.Contains(pb.ToString(), "(at 10.5 (not (open d1)))") {
+		t.Errorf("the timed initials aren't printed as declared:\n%s", pb.ToString())
+	}
+	_, again, err := ParseTexts(&config.Config{}, d.ToString(), pb.ToString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ToString() != pb.ToString() {
+		t.Errorf("the printed problem parses differently")
+	}
+	s, err := pb.InitialState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Holds("open", []string{"d1"}) || s.Holds("open", []string{"d2"}) {
+		t.Errorf("got initial state %s, timed initials applied too early", s.ToString())
+	}
+}
+
+func TestTimedInitialsRequirement(t *testing.T) {
+	_, _, err := ParseTexts(&config.Config{}, tilsDomain(""), tilsProblem)
+	want := "timed initial literals require :timed-initial-literals"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,213 @@
+package simulator
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// GroundAction is an action with every parameter bound to an object.
+type GroundAction struct {
+	Action    *models.Action
+	Arguments []string
+}
+
+func (ga *GroundAction) ToString() string {
+	return models.AtomKey(ga.Action.Name.Name, ga.Arguments)
+}
+
+func (ga *GroundAction) bindings() models.Bindings {
+	b := models.Bindings{}
+	for i, p := range ga.Action.Params {
+		b[p.Name.Name] = ga.Arguments[i]
+	}
+	return b
+}
+
+// Simulator steps through the states of a task one action at a time
+// and keeps the history needed to undo them.
+type Simulator struct {
+	Domain   *models.Domain
+	Problem  *models.Problem
+	actions  map[string]*models.Action
+	universe models.Universe
+	states   []*models.State
+	applied  []*GroundAction
+}
+
+func NewSimulator(d *models.Domain, pb *models.Problem) (*Simulator, error) {
+	if d == nil || pb == nil {
+		return nil, fmt.Errorf("Failed to create simulator: domain or problem is nil")
+	}
+	init, err := pb.InitialState()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create simulator: %v", err)
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create simulator: %v", err)
+	}
+	acts := map[string]*models.Action{}
+	for _, a := range d.Actions {
+		acts[a.Name.Name] = a
+	}
+	return &Simulator{
+		Domain:   d,
+		Problem:  pb,
+		actions:  acts,
+		universe: u,
+		states:   []*models.State{init},
+	}, nil
+}
+
+// State returns the current state. It must not be modified.
+func (s *Simulator) State() *models.State {
+	return s.states[len(s.states)-1]
+}
+
+func (s *Simulator) InitialState() *models.State {
+	return s.states[0]
+}
+
+// History returns the actions applied so far, in order.
+func (s *Simulator) History() []*GroundAction {
+	return append([]*GroundAction{}, s.applied...)
+}
+
+func (s *Simulator) env(state *models.State) *models.Env {
+	return &models.Env{
+		State:    state,
+		Universe: s.universe,
+		Time:     float64(len(s.applied)),
+	}
+}
+
+// Ground binds an action to its arguments, checking arity and types.
+func (s *Simulator) Ground(name string, args ...string) (*GroundAction, error) {
+	act, ok := s.actions[name]
+	if !ok {
+		return nil, fmt.Errorf("Failed to ground action: unknown action %s", name)
+	}
+	if len(args) != len(act.Params) {
+		return nil, fmt.Errorf("Failed to ground action: %s expects %d arguments, got %d", name, len(act.Params), len(args))
+	}
+	for i, p := range act.Params {
+		if !s.universe.HasType(args[i], p.Types) {
+			return nil, fmt.Errorf("Failed to ground action: %s is not a valid %s for %s", args[i], p.Name.Name, name)
+		}
+	}
+	return &GroundAction{
+		Action:    act,
+		Arguments: args,
+	}, nil
+}
+
+// Evaluate evaluates a ground goal description in the current state.
+func (s *Simulator) Evaluate(f models.Formula) (bool, error) {
+	return models.Evaluate(f, s.env(s.State()), models.Bindings{})
+}
+
+// IsApplicable tells whether the precondition of the action holds in
+// the current state.
+func (s *Simulator) IsApplicable(ga *GroundAction) (bool, error) {
+	ok, err := models.Evaluate(ga.Action.Precondition, s.env(s.State()), ga.bindings())
+	if err != nil {
+		return false, fmt.Errorf("Failed to check %s: %v", ga.ToString(), err)
+	}
+	return ok, nil
+}
+
+// Applicable lists every ground action applicable in the current state.
+func (s *Simulator) Applicable() ([]*GroundAction, error) {
+	env := s.env(s.State())
+	gas := []*GroundAction{}
+	for _, act := range s.Domain.Actions {
+		act := act
+		_, err := env.Each(act.Params, models.Bindings{}, func(b models.Bindings) (bool, error) {
+			ok, err := models.Evaluate(act.Precondition, env, b)
+			if err != nil || !ok {
+				return false, err
+			}
+			args := make([]string, len(act.Params))
+			for i, p := range act.Params {
+				args[i] = b[p.Name.Name]
+			}
+			gas = append(gas, &GroundAction{
+				Action:    act,
+				Arguments: args,
+			})
+			return false, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list applicable actions: %v", err)
+		}
+	}
+	return gas, nil
+}
+
+// Successor returns the state reached by applying the action in the
+// current state, without moving the simulator.
+func (s *Simulator) Successor(ga *GroundAction) (*models.State, error) {
+	ok, err := s.IsApplicable(ga)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("Failed to apply %s: precondition is not satisfied", ga.ToString())
+	}
+	next, err := models.Apply(ga.Action.Effect, s.env(s.State()), ga.bindings())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to apply %s: %v", ga.ToString(), err)
+	}
+	return next, nil
+}
+
+// Outcomes returns the possible changes of the action in the current
+// state with their probabilities, as for PPDDL probabilistic effects.
+// Deterministic actions have a single outcome.
+func (s *Simulator) Outcomes(ga *GroundAction) ([]*models.Outcome, error) {
+	ok, err := s.IsApplicable(ga)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("Failed to apply %s: precondition is not satisfied", ga.ToString())
+	}
+	outs, err := models.Outcomes(ga.Action.Effect, s.env(s.State()), ga.bindings())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to apply %s: %v", ga.ToString(), err)
+	}
+	return outs, nil
+}
+
+// Apply applies the action and moves to the successor state.
+func (s *Simulator) Apply(ga *GroundAction) (*models.State, error) {
+	next, err := s.Successor(ga)
+	if err != nil {
+		return nil, err
+	}
+	s.states = append(s.states, next)
+	s.applied = append(s.applied, ga)
+	return next, nil
+}
+
+// Undo reverts the last applied action.
+func (s *Simulator) Undo() error {
+	if len(s.applied) == 0 {
+		return fmt.Errorf("Failed to undo: no action applied")
+	}
+	s.states = s.states[:len(s.states)-1]
+	s.applied = s.applied[:len(s.applied)-1]
+	return nil
+}
+
+// Reset goes back to the initial state.
+func (s *Simulator) Reset() {
+	s.states = s.states[:1]
+	s.applied = nil
+}
+
+// GoalReached tells whether the goal holds in the current state.
+func (s *Simulator) GoalReached() (bool, error) {
+	return s.Evaluate(s.Problem.Goal)
+}
@@ -0,0 +1,149 @@
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/parser"
+)
+
+const testDomain = `(define (domain blocks)
+  (:requirements :strips :typing)
+  (:types block)
+  (:predicates (on ?x - block ?y - block) (ontable ?x - block)
+               (clear ?x - block) (handempty) (holding ?x - block))
+  (:action pick-up
+    :parameters (?x - block)
+    :precondition (and (clear ?x) (ontable ?x) (handempty))
+    :effect (and (not (ontable ?x)) (not (clear ?x)) (not (handempty)) (holding ?x)))
+  (:action stack
+    :parameters (?x - block ?y - block)
+    :precondition (and (holding ?x) (clear ?y))
+    :effect (and (not (holding ?x)) (not (clear ?y)) (clear ?x) (handempty) (on ?x ?y))))
+`
+
+const testProblem = `(define (problem three) (:domain blocks)
+  (:objects a b c - block)
+  (:init (handempty) (ontable a) (ontable b) (ontable c) (clear a) (clear b) (clear c))
+  (:goal (on a b)))
+`
+
+func newSimulator(t *testing.T, domain string, problem string) *Simulator {
+	d, pb, err := parser.ParseTexts(&config.Config{}, domain, problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSimulator(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestGround(t *testing.T) {
+	s := newSimulator(t, testDomain, testProblem)
+	if _, err := s.Ground("pick-up", "a"); err != nil {
+		t.Error(err)
+	}
+	for _, args := range [][]string{{"fly", "a"}, {"pick-up"}, {"pick-up", "d"}} {
+		if _, err := s.Ground(args[0], args[1:]...); err == nil {
+			t.Errorf("%v: got no error", args)
+		}
+	}
+}
+
+func TestApplyUndo(t *testing.T) {
+	s := newSimulator(t, testDomain, testProblem)
+	gas, err := s.Applicable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gas) != 3 {
+		t.Errorf("got %d applicable actions in the initial state, want 3", len(gas))
+	}
+	stack, err := s.Ground("stack", "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Apply(stack); err == nil {
+		t.Errorf("applying %s in the initial state: got no error", stack.ToString())
+	}
+	pick, err := s.Ground("pick-up", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ga := range []*GroundAction{pick, stack} {
+		if _, err := s.Apply(ga); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ok, err := s.GoalReached()
+	if err != nil || !ok {
+		t.Errorf("goal not reached after (pick-up a) (stack a b): %v", err)
+	}
+	if len(s.History()) != 2 || !s.State().Holds("on", []string{"a", "b"}) {
+		t.Errorf("got history %d and state %s", len(s.History()), s.State().ToString())
+	}
+	if err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if !s.State().Holds("holding", []string{"a"}) || s.State().Holds("on", []string{"a", "b"}) {
+		t.Errorf("got state %s after undo", s.State().ToString())
+	}
+	s.Reset()
+	if s.State() != s.InitialState() || len(s.History()) != 0 {
+		t.Errorf("got state %s after reset", s.State().ToString())
+	}
+	if err := s.Undo(); err == nil {
+		t.Errorf("undo in the initial state: got no error")
+	}
+}
+
+func TestSuccessor(t *testing.T) {
+	s := newSimulator(t, testDomain, testProblem)
+	pick, err := s.Ground("pick-up", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := s.Successor(pick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.Holds("holding", []string{"c"}) || next.Holds("handempty", nil) {
+		t.Errorf("got successor %s", next.ToString())
+	}
+	if s.State() != s.InitialState() {
+		t.Errorf("successor moved the simulator")
+	}
+}
+
+func TestOutcomes(t *testing.T) {
+	domain := `(define (domain coin)
+  (:requirements :probabilistic-effects)
+  (:predicates (heads) (tails))
+  (:action toss
+    :parameters ()
+    :effect (probabilistic 0.7 (heads) 0.2 (tails))))
+`
+	problem := `(define (problem c) (:domain coin) (:init) (:goal (heads)))`
+	s := newSimulator(t, domain, problem)
+	toss, err := s.Ground("toss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outs, err := s.Outcomes(toss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outs) != 3 {
+		t.Fatalf("got %d outcomes, want 3", len(outs))
+	}
+	sum := 0.0
+	for _, o := range outs {
+		sum += o.Probability
+	}
+	if math.Abs(sum-1) > 1e-9 || math.Abs(outs[2].Probability-0.1) > 1e-9 {
+		t.Errorf("got probabilities summing to %g, remainder %g", sum, outs[2].Probability)
+	}
+}
@@ -0,0 +1,334 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// MultiAgentTask is the merge of the factors of a MA-PDDL task into a
+// single domain and problem. It remembers which agents declared each
+// private predicate, private object and action, for the projections.
+type MultiAgentTask struct {
+	Domain  *models.Domain
+	Problem *models.Problem
+	Agents  []string
+	// owners of the private predicates, of the private objects and
+	// constants, and of the actions
+	predicates map[string]map[string]bool
+	objects    map[string]map[string]bool
+	actions    map[string]map[string]bool
+}
+
+func own(owners map[string]map[string]bool, name string, agent string) {
+	if owners[name] == nil {
+		owners[name] = map[string]bool{}
+	}
+	owners[name][agent] = true
+}
+
+func typeNames(te *models.TypedEntry) string {
+	ns := []string{}
+	for _, t := range te.Types {
+		ns = append(ns, t.Name.Name)
+	}
+	return strings.Join(ns, " ")
+}
+
+// mergeEntries appends the entries not declared yet, failing when a
+// name is declared with different types.
+func mergeEntries(tes []*models.TypedEntry, news []*models.TypedEntry, owners map[string]map[string]bool, agent string) ([]*models.TypedEntry, error) {
+	for _, n := range news {
+		var found *models.TypedEntry
+		for _, te := range tes {
+			if te.Name.Name == n.Name.Name {
+				found = te
+				break
+			}
+		}
+		if found != nil && typeNames(found) != typeNames(n) {
+			return nil, fmt.Errorf("%s is declared with types %q and %q", n.Name.Name, typeNames(found), typeNames(n))
+		}
+		if found == nil {
+			tes = append(tes, n)
+			found = n
+		}
+		if n.Private {
+			found.Private = true
+			own(owners, n.Name.Name, agent)
+		}
+	}
+	return tes, nil
+}
+
+func mergeNames(ns []*models.Name, news []*models.Name) []*models.Name {
+	seen := map[string]bool{}
+	for _, n := range ns {
+		seen[n.Name] = true
+	}
+	for _, n := range news {
+		if !seen[n.Name] {
+			seen[n.Name] = true
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
+
+// Merge builds the single task of the factors of a MA-PDDL task.
+// Declarations shared by several agents are merged; actions with the
+// same name but different definitions are renamed with a numeric
+// suffix.
+func Merge(fs []*models.Factor) (*MultiAgentTask, error) {
+	if len(fs) == 0 {
+		return nil, fmt.Errorf("Failed to merge agents: no agent")
+	}
+	t := &MultiAgentTask{
+		predicates: map[string]map[string]bool{},
+		objects:    map[string]map[string]bool{},
+		actions:    map[string]map[string]bool{},
+	}
+	used := map[string]bool{}
+	var goals []models.Formula
+	inits := map[string]bool{}
+	for _, f := range fs {
+		if f.Domain == nil || f.Problem == nil {
+			return nil, fmt.Errorf("Failed to merge agent %s: domain or problem is nil", f.Agent)
+		}
+		for _, a := range t.Agents {
+			if a == f.Agent {
+				return nil, fmt.Errorf("Failed to merge agents: %s is declared twice", f.Agent)
+			}
+		}
+		d, pb := f.Domain.Clone(), f.Problem.Clone()
+		if len(d.Processes) > 0 || len(d.Events) > 0 || len(d.Tasks) > 0 || len(d.Methods) > 0 {
+			return nil, fmt.Errorf("Failed to merge agent %s: only actions can be merged", f.Agent)
+		}
+		t.Agents = append(t.Agents, f.Agent)
+		if t.Domain == nil {
+			t.Domain = &models.Domain{
+				Name: d.Name,
+			}
+			t.Problem = &models.Problem{
+				Name:   pb.Name,
+				Domain: pb.Domain,
+				Metric: pb.Metric,
+			}
+		}
+		nd, npb := t.Domain, t.Problem
+		nd.Requirements = mergeNames(nd.Requirements, d.Requirements)
+	types:
+		for _, tp := range d.Types {
+			for _, ntp := range nd.Types {
+				if ntp.TypedEntry.Name.Name == tp.TypedEntry.Name.Name {
+					continue types
+				}
+			}
+			nd.Types = append(nd.Types, tp)
+		}
+		var err error
+		nd.Constants, err = mergeEntries(nd.Constants, d.Constants, t.objects, f.Agent)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to merge agent %s: %v", f.Agent, err)
+		}
+	predicates:
+		for _, p := range d.Predicates {
+			if p.Private {
+				own(t.predicates, p.Name.Name, f.Agent)
+			}
+			for _, np := range nd.Predicates {
+				if np.Name.Name != p.Name.Name {
+					continue
+				}
+				if len(np.Parameters) != len(p.Parameters) {
+					return nil, fmt.Errorf("Failed to merge agent %s: predicate %s is declared with different arities", f.Agent, p.Name.Name)
+				}
+				np.Private = np.Private || p.Private
+				continue predicates
+			}
+			nd.Predicates = append(nd.Predicates, p)
+		}
+	functions:
+		for _, fn := range d.Functions {
+			for _, nfn := range nd.Functions {
+				if nfn.Name.Name == fn.Name.Name {
+					continue functions
+				}
+			}
+			nd.Functions = append(nd.Functions, fn)
+		}
+	actions:
+		for _, a := range d.Actions {
+			for _, na := range nd.Actions {
+				if na.Name.Name == a.Name.Name && toStringActionBody(na) == toStringActionBody(a) {
+					own(t.actions, na.Name.Name, f.Agent)
+					continue actions
+				}
+			}
+			if used[a.Name.Name] {
+				a.Name = &models.Name{
+					Name:     uniqueName(a.Name.Name, used),
+					Location: a.Name.Location,
+				}
+			}
+			used[a.Name.Name] = true
+			own(t.actions, a.Name.Name, f.Agent)
+			nd.Actions = append(nd.Actions, a)
+		}
+
+		npb.Requirements = mergeNames(npb.Requirements, pb.Requirements)
+		npb.Objects, err = mergeEntries(npb.Objects, pb.Objects, t.objects, f.Agent)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to merge agent %s: %v", f.Agent, err)
+		}
+		for _, i := range pb.InitialConditions {
+			if s := i.ToString(""); !inits[s] {
+				inits[s] = true
+				npb.InitialConditions = append(npb.InitialConditions, i)
+			}
+		}
+		if pb.Goal != nil {
+			goals = append(goals, conjuncts(pb.Goal)...)
+		}
+		if npb.Metric == nil {
+			npb.Metric = pb.Metric
+		}
+	}
+	t.Problem.Goal = conjunction(goals)
+	return t, nil
+}
+
+// toStringActionBody prints an action without its name.
+func toStringActionBody(a *models.Action) string {
+	c := *a
+	c.Name = &models.Name{}
+	d := &models.Domain{
+		Name:    &models.Name{},
+		Actions: []*models.Action{&c},
+	}
+	return d.ToString()
+}
+
+// conjunction returns the conjunction of the distinct formulas, nil
+// when there is none.
+func conjunction(fs []models.Formula) models.Formula {
+	seen := map[string]bool{}
+	distinct := []models.Formula{}
+	for _, f := range fs {
+		if s := f.ToString(""); !seen[s] {
+			seen[s] = true
+			distinct = append(distinct, f)
+		}
+	}
+	switch len(distinct) {
+	case 0:
+		return nil
+	case 1:
+		return distinct[0]
+	}
+	return newAnd(location(distinct[0]), distinct)
+}
+
+// Project returns the view of the task of an agent: its actions, the
+// public predicates and objects, and its own private ones. Initial
+// facts and goals mentioning what the agent doesn't know are left out.
+func (t *MultiAgentTask) Project(agent string) (*models.Domain, *models.Problem, error) {
+	known := false
+	for _, a := range t.Agents {
+		known = known || a == agent
+	}
+	if !known {
+		return nil, nil, fmt.Errorf("Failed to project task: unknown agent %s", agent)
+	}
+	hiddenPreds, hiddenObjs := map[string]bool{}, map[string]bool{}
+	for p, owners := range t.predicates {
+		hiddenPreds[p] = !owners[agent]
+	}
+	for o, owners := range t.objects {
+		hiddenObjs[o] = !owners[agent]
+	}
+	nd, npb := t.Domain.Clone(), t.Problem.Clone()
+
+	preds := []*models.Predicate{}
+	for _, p := range nd.Predicates {
+		if !hiddenPreds[p.Name.Name] {
+			preds = append(preds, p)
+		}
+	}
+	nd.Predicates = preds
+	nd.Constants = visibleEntries(nd.Constants, hiddenObjs)
+	acts := []*models.Action{}
+	for _, a := range nd.Actions {
+		if t.actions[a.Name.Name][agent] {
+			acts = append(acts, a)
+		}
+	}
+	nd.Actions = acts
+
+	npb.Objects = visibleEntries(npb.Objects, hiddenObjs)
+	inits := []models.Formula{}
+	for _, i := range npb.InitialConditions {
+		if visible(i, hiddenPreds, hiddenObjs) {
+			inits = append(inits, i)
+		}
+	}
+	npb.InitialConditions = inits
+	goals := []models.Formula{}
+	for _, g := range conjuncts(npb.Goal) {
+		if visible(g, hiddenPreds, hiddenObjs) {
+			goals = append(goals, g)
+		}
+	}
+	npb.Goal = conjunction(goals)
+	return nd, npb, nil
+}
+
+func visibleEntries(tes []*models.TypedEntry, hidden map[string]bool) []*models.TypedEntry {
+	if tes == nil {
+		return nil
+	}
+	out := []*models.TypedEntry{}
+	for _, te := range tes {
+		if !hidden[te.Name.Name] {
+			out = append(out, te)
+		}
+	}
+	return out
+}
+
+// visible tells whether the formula only mentions predicates and
+// objects that aren't hidden.
+func visible(f models.Formula, preds map[string]bool, objs map[string]bool) bool {
+	ok := true
+	var terms func(ts []*models.Term)
+	terms = func(ts []*models.Term) {
+		for _, t := range ts {
+			if t == nil {
+				continue
+			}
+			if !t.IsVariable && objs[t.Name.Name] {
+				ok = false
+			}
+			if t.Function != nil {
+				terms(t.Function.Terms)
+			}
+		}
+	}
+	models.Inspect(f, func(f models.Formula) bool {
+		switch n := f.(type) {
+		case *models.LiteralNode:
+			if preds[n.Predicate.Name] {
+				ok = false
+			}
+			terms(n.Terms)
+		case *models.AssignNode:
+			if n.AssignedTo != nil {
+				terms(n.AssignedTo.Terms)
+			}
+			terms([]*models.Term{n.Term})
+		}
+		return ok
+	})
+	return ok
+}
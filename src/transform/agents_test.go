@@ -0,0 +1,148 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// roverFactor returns the factor of a rover agent, whose private
+// predicates and objects carry its name. extra is added to the public
+// objects.
+func roverFactor(t *testing.T, agent string, extra string) *models.Factor {
+	domain := `(define (domain rovers)
+  (:requirements :typing :factored-privacy)
+  (:types rover waypoint sample)
+  (:predicates (at ?r - rover ?w - waypoint) (have ?s - sample)
+    (:private (can-traverse-AG ?x - waypoint ?y - waypoint) (ready-AG)))
+  (:action navigate
+    :parameters (?r - rover ?x - waypoint ?y - waypoint)
+    :precondition (and (at ?r ?x) (can-traverse-AG ?x ?y))
+    :effect (and (at ?r ?y) (not (at ?r ?x))))
+  (:action sample
+    :parameters (?r - rover ?w - waypoint ?s - sample)
+    :precondition (at ?r ?w)
+    :effect (have ?s)))
+`
+	problem := `(define (problem rp) (:domain rovers)
+  (:objects w1 w2 - waypoint s1 - sample ` + extra + ` (:private AG - rover secret-AG - waypoint))
+  (:init (at AG w1) (can-traverse-AG w1 w2) (can-traverse-AG w2 secret-AG) (ready-AG))
+  (:goal (and (have s1) (at AG secret-AG))))
+`
+	d, pb := parseTask(t, strings.Replace(domain, "AG", agent, -1), strings.Replace(problem, "AG", agent, -1))
+	return &models.Factor{
+		Agent:   agent,
+		Domain:  d,
+		Problem: pb,
+	}
+}
+
+func names(d *models.Domain, pb *models.Problem) (string, string, string) {
+	preds, acts, objs := []string{}, []string{}, []string{}
+	for _, p := range d.Predicates {
+		preds = append(preds, p.Name.Name)
+	}
+	for _, a := range d.Actions {
+		acts = append(acts, a.Name.Name)
+	}
+	for _, o := range pb.Objects {
+		objs = append(objs, o.Name.Name)
+	}
+	return strings.Join(preds, " "), strings.Join(acts, " "), strings.Join(objs, " ")
+}
+
+func TestMerge(t *testing.T) {
+	task, err := Merge([]*models.Factor{roverFactor(t, "r1", ""), roverFactor(t, "r2", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	preds, acts, objs := names(task.Domain, task.Problem)
+	if preds != "at have can-traverse-r1 ready-r1 can-traverse-r2 ready-r2" {
+		t.Errorf("got predicates %s", preds)
+	}
+	// The navigate actions differ, the sample ones are shared.
+	if acts != "navigate sample navigate-1" {
+		t.Errorf("got actions %s", acts)
+	}
+	if objs != "w1 w2 s1 r1 secret-r1 r2 secret-r2" {
+		t.Errorf("got objects %s", objs)
+	}
+	if len(task.Problem.InitialConditions) != 8 {
+		t.Errorf("got %d initial facts, want 8", len(task.Problem.InitialConditions))
+	}
+	want := "(and (have s1) (at r1 secret-r1) (at r2 secret-r2))"
+	if got := text(task.Problem.Goal); got != want {
+		t.Errorf("got goal %s, want %s", got, want)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	r1 := roverFactor(t, "r1", "")
+	arity := roverFactor(t, "r2", "")
+	arity.Domain.Predicates[1].Parameters = nil
+	// s1 is a sample for r1 and a waypoint for r3.
+	conflict := roverFactor(t, "r3", "")
+	conflict.Problem.Objects[2].Types[0].Name.Name = "waypoint"
+	tests := []struct {
+		fs   []*models.Factor
+		want string
+	}{
+		{nil, "no agent"},
+		{[]*models.Factor{r1, roverFactor(t, "r1", "")}, "r1 is declared twice"},
+		{[]*models.Factor{r1, roverFactor(t, "r2", "s2 - waypoint")}, ""},
+		{[]*models.Factor{r1, arity}, "predicate have is declared with different arities"},
+		{[]*models.Factor{r1, conflict}, `s1 is declared with types "sample" and "waypoint"`},
+	}
+	for _, test := range tests {
+		_, err := Merge(test.fs)
+		if test.want == "" {
+			if err != nil {
+				t.Errorf("got error %v", err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("got error %v, want %q", err, test.want)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	task, err := Merge([]*models.Factor{roverFactor(t, "r1", ""), roverFactor(t, "r2", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, pb, err := task.Project("r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	preds, acts, objs := names(d, pb)
+	if preds != "at have can-traverse-r1 ready-r1" {
+		t.Errorf("got predicates %s", preds)
+	}
+	if acts != "navigate sample" {
+		t.Errorf("got actions %s", acts)
+	}
+	if objs != "w1 w2 s1 r1 secret-r1" {
+		t.Errorf("got objects %s", objs)
+	}
+	inits := []string{}
+	for _, f := range pb.InitialConditions {
+		inits = append(inits, text(f))
+	}
+	want := "(at r1 w1) (can-traverse-r1 w1 w2) (can-traverse-r1 w2 secret-r1) (ready-r1)"
+	if got := strings.Join(inits, " "); got != want {
+		t.Errorf("got initial facts %s, want %s", got, want)
+	}
+	want = "(and (have s1) (at r1 secret-r1))"
+	if got := text(pb.Goal); got != want {
+		t.Errorf("got goal %s, want %s", got, want)
+	}
+	if len(task.Domain.Predicates) != 6 {
+		t.Errorf("projecting modified the merged task")
+	}
+	if _, _, err := task.Project("r9"); err == nil {
+		t.Errorf("projecting on r9: got no error")
+	}
+}
@@ -0,0 +1,386 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+const (
+	// maxSplitConditionalEffects bounds the number of conditional
+	// effects of an action for the exponential compilation.
+	maxSplitConditionalEffects = 16
+)
+
+// conditionalParts splits an effect into its unconditional effects
+// and its conditional effects. Universally quantified effects that
+// contain conditional effects are expanded over the universe first.
+func conditionalParts(eff models.Formula, u models.Universe) ([]models.Formula, []*models.WhenNode) {
+	hasWhen := false
+	models.Inspect(eff, func(f models.Formula) bool {
+		if _, ok := f.(*models.WhenNode); ok {
+			hasWhen = true
+		}
+		return !hasWhen
+	})
+	if !hasWhen {
+		return conjuncts(eff), nil
+	}
+	uncond := []models.Formula{}
+	conds := []*models.WhenNode{}
+	for _, f := range conjuncts(newAnd(location(eff), conjuncts(Expand(eff, u)))) {
+		if w, ok := f.(*models.WhenNode); ok {
+			conds = append(conds, w)
+			continue
+		}
+		uncond = append(uncond, f)
+	}
+	return uncond, conds
+}
+
+// CompileConditionalEffectsExponential replaces every action with k
+// conditional effects by 2^k actions, one for each subset of effects
+// that fire, whose precondition requires exactly their conditions.
+// Processes and events are split alike: their copies exclude each
+// other, so only one happens. Subtasks of methods can't be split.
+func CompileConditionalEffectsExponential(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil || pb == nil {
+		return nil, nil, fmt.Errorf("Failed to compile conditional effects: domain or problem is nil")
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to compile conditional effects: %v", err)
+	}
+	nd := d.Clone()
+	used := structureNames(d)
+	err = mapStructures(nd, func(kind string, a *models.Action) ([]*models.Action, error) {
+		uncond, conds := conditionalParts(a.Effect, u)
+		if len(conds) == 0 {
+			return []*models.Action{a}, nil
+		}
+		if isSubtask(d, pb, a.Name.Name) {
+			return nil, fmt.Errorf("Failed to compile conditional effects: %s %s has conditional effects and is a subtask", kind, a.Name.Name)
+		}
+		if len(conds) > maxSplitConditionalEffects {
+			return nil, fmt.Errorf("Failed to compile conditional effects: %s %s has %d conditional effects, use the polynomial compilation",
+				kind, a.Name.Name, len(conds))
+		}
+		acts := []*models.Action{}
+		for set := 0; set < 1<<uint(len(conds)); set++ {
+			c := a.Clone()
+			if set > 0 {
+				c.Name = &models.Name{
+					Name:     uniqueName(a.Name.Name, used),
+					Location: a.Name.Location,
+				}
+			}
+			pre := conjuncts(models.CloneFormula(a.Precondition))
+			eff := []models.Formula{}
+			for _, f := range uncond {
+				eff = append(eff, models.CloneFormula(f))
+			}
+			for i, w := range conds {
+				if set&(1<<uint(i)) != 0 {
+					pre = append(pre, NNF(w.Condition))
+					eff = append(eff, conjuncts(models.CloneFormula(w.UnaryNode.Formula))...)
+				} else {
+					pre = append(pre, NNF(newNot(location(w), w.Condition)))
+				}
+			}
+			c.Precondition = newAnd(location(a.Precondition), pre)
+			c.Effect = newAnd(location(a.Effect), eff)
+			acts = append(acts, c)
+		}
+		return acts, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	nd.Requirements = adjustRequirements(nd, ":conditional-effects")
+	npb := pb.Clone()
+	npb.Requirements = removeRequirements(npb.Requirements, ":conditional-effects")
+	return nd, npb, nil
+}
+
+// splitDeletes separates the delete effects from the add and numeric
+// effects, keeping universal quantifiers around both parts.
+func splitDeletes(fs []models.Formula) (dels []models.Formula, adds []models.Formula) {
+	for _, f := range fs {
+		switch n := f.(type) {
+		case *models.LiteralNode:
+			if n.Negative {
+				dels = append(dels, f)
+			} else {
+				adds = append(adds, f)
+			}
+		case *models.ForAllNode:
+			d, a := splitDeletes(conjuncts(n.QuantNode.UnaryNode.Formula))
+			for _, part := range []struct {
+				fs  []models.Formula
+				out *[]models.Formula
+			}{{d, &dels}, {a, &adds}} {
+				if len(part.fs) == 0 {
+					continue
+				}
+				*part.out = append(*part.out, &models.ForAllNode{
+					QuantNode: &models.QuantNode{
+						Variables: models.CloneTypedEntries(n.QuantNode.Variables),
+						UnaryNode: &models.UnaryNode{
+							Node:    n.QuantNode.UnaryNode.Node,
+							Formula: newAnd(location(n), part.fs),
+						},
+					},
+					IsEffect: true,
+				})
+			}
+		default:
+			adds = append(adds, f)
+		}
+	}
+	return
+}
+
+// auxiliaries creates the auxiliary predicates of a compilation and
+// the literals over them.
+type auxiliaries struct {
+	domain *models.Domain
+	used   map[string]bool
+}
+
+func newAuxiliaries(d *models.Domain) *auxiliaries {
+	used := map[string]bool{}
+	for _, p := range d.Predicates {
+		used[p.Name.Name] = true
+	}
+	return &auxiliaries{
+		domain: d,
+		used:   used,
+	}
+}
+
+// predicate declares a new predicate over the parameters and returns
+// its name.
+func (aux *auxiliaries) predicate(name string, params []*models.TypedEntry, loc *models.Location) string {
+	name = uniqueName(name, aux.used)
+	aux.domain.Predicates = append(aux.domain.Predicates, &models.Predicate{
+		Name: &models.Name{
+			Name:     name,
+			Location: loc,
+		},
+		Parameters: models.CloneTypedEntries(params),
+	})
+	return name
+}
+
+// checkNumericEffects checks that no numeric effect of the action
+// reads a fluent that another of its numeric effects changes.
+func checkNumericEffects(a *models.Action, uncond []models.Formula, conds []*models.WhenNode) error {
+	effs := append([]models.Formula{}, uncond...)
+	for _, w := range conds {
+		effs = append(effs, w)
+	}
+	assigns := []*models.AssignNode{}
+	for _, f := range effs {
+		models.Inspect(f, func(f models.Formula) bool {
+			if n, ok := f.(*models.AssignNode); ok {
+				assigns = append(assigns, n)
+			}
+			return true
+		})
+	}
+	for i, n := range assigns {
+		read := map[string]bool{}
+		readFunctions(n, read)
+		for j, m := range assigns {
+			if i != j && read[m.AssignedTo.Name.Name] {
+				return fmt.Errorf("%s of %s reads %s, which %s changes", n.ToString(""), a.Name.Name, m.AssignedTo.Name.Name, m.ToString(""))
+			}
+		}
+	}
+	return nil
+}
+
+// readFunctions adds the functions the assignment reads to read.
+func readFunctions(n *models.AssignNode, read map[string]bool) {
+	var term func(t *models.Term)
+	var head func(fi *models.FunctionInit)
+	head = func(fi *models.FunctionInit) {
+		read[fi.Name.Name] = true
+		for _, t := range fi.Terms {
+			term(t)
+		}
+	}
+	term = func(t *models.Term) {
+		if t.Function != nil {
+			head(t.Function)
+		}
+	}
+	var expr func(e *models.NumericExpression)
+	expr = func(e *models.NumericExpression) {
+		if e.FunctionInit != nil {
+			head(e.FunctionInit)
+		}
+		for _, o := range e.Operands {
+			expr(o)
+		}
+	}
+	for _, t := range n.AssignedTo.Terms {
+		term(t)
+	}
+	switch {
+	case n.Term != nil:
+		term(n.Term)
+	case n.FunctionInit != nil:
+		head(n.FunctionInit)
+	case n.Expression != nil:
+		expr(n.Expression)
+	}
+}
+
+// atom returns the literal of the predicate over the parameters.
+func atom(pred string, params []*models.TypedEntry, negative bool, effect bool, loc *models.Location) *models.LiteralNode {
+	terms := make([]*models.Term, len(params))
+	for i, p := range params {
+		terms[i] = &models.Term{
+			Name:       p.Name,
+			IsVariable: true,
+		}
+	}
+	return newLiteral(loc, pred, negative, effect, terms)
+}
+
+// CompileConditionalEffectsPolynomial compiles conditional effects
+// away with a linear number of actions, in the spirit of Nebel's
+// compilation. Every action with k conditional effects becomes a
+// sequence: a start action checks the precondition and leaves normal
+// mode, one stage per conditional effect records whether it fires,
+// then the fired effects apply their deletes, then their adds, and
+// an end action applies the unconditional adds and returns to normal
+// mode. Only one action is in progress at a time, and the goal
+// requires normal mode. Unconditional deletes apply with the last
+// evaluation stage so that adds still win over deletes. Numeric
+// effects apply in stages too, so an action whose numeric effects read
+// fluents changed by its other effects is rejected, as they would read
+// the changed values rather than those before the action. Processes
+// and events wait for normal mode, and neither they nor the subtasks of
+// methods can have conditional effects.
+func CompileConditionalEffectsPolynomial(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil || pb == nil {
+		return nil, nil, fmt.Errorf("Failed to compile conditional effects: domain or problem is nil")
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to compile conditional effects: %v", err)
+	}
+	nd := d.Clone()
+	npb := pb.Clone()
+	aux := newAuxiliaries(nd)
+	usedActs := structureNames(d)
+	loc := &models.Location{
+		Path: d.Name.Location.Path,
+		Line: d.Name.Location.Line,
+	}
+	normal := aux.predicate("normal-mode", nil, loc)
+	err = mapStructures(nd, func(kind string, a *models.Action) ([]*models.Action, error) {
+		uncond, conds := conditionalParts(a.Effect, u)
+		aloc := a.Name.Location
+		if len(conds) == 0 {
+			a.Precondition = newAnd(location(a.Precondition), append(conjuncts(a.Precondition),
+				atom(normal, nil, false, false, aloc)))
+			return []*models.Action{a}, nil
+		}
+		if kind != "action" {
+			return nil, fmt.Errorf("Failed to compile conditional effects: %s %s has conditional effects", kind, a.Name.Name)
+		}
+		if isSubtask(d, pb, a.Name.Name) {
+			return nil, fmt.Errorf("Failed to compile conditional effects: action %s has conditional effects and is a subtask", a.Name.Name)
+		}
+		err := checkNumericEffects(a, uncond, conds)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile conditional effects: %v", err)
+		}
+		acts := []*models.Action{}
+		k := len(conds)
+		pending := aux.predicate(a.Name.Name+"-pending", a.Params, aloc)
+		fired := make([]string, k)
+		skipped := make([]string, k)
+		for i := range conds {
+			fired[i] = aux.predicate(fmt.Sprintf("%s-fired-%d", a.Name.Name, i+1), a.Params, aloc)
+			skipped[i] = aux.predicate(fmt.Sprintf("%s-skipped-%d", a.Name.Name, i+1), a.Params, aloc)
+		}
+		// Stages are ordered: k evaluations, k deletes, k adds, end.
+		stages := make([]string, 3*k+1)
+		for i := range stages {
+			stages[i] = aux.predicate(fmt.Sprintf("%s-stage-%d", a.Name.Name, i+1), nil, aloc)
+		}
+		stage := func(i int, negative bool) models.Formula {
+			return atom(stages[i], nil, negative, negative, aloc)
+		}
+		step := func(name string, pre []models.Formula, eff []models.Formula) {
+			c := a.Clone()
+			c.Name = &models.Name{
+				Name:     uniqueName(name, usedActs),
+				Location: aloc,
+			}
+			c.Precondition = newAnd(aloc, pre)
+			c.Effect = newAnd(aloc, eff)
+			acts = append(acts, c)
+		}
+		pend := func() models.Formula {
+			return atom(pending, a.Params, false, false, aloc)
+		}
+		uncondDels, uncondAdds := splitDeletes(uncond)
+
+		step(a.Name.Name+"-start",
+			append(conjuncts(models.CloneFormula(a.Precondition)), atom(normal, nil, false, false, aloc)),
+			[]models.Formula{atom(normal, nil, true, true, aloc), atom(pending, a.Params, false, true, aloc), stage(0, false)})
+		for i, w := range conds {
+			var last []models.Formula
+			if i == k-1 {
+				for _, f := range uncondDels {
+					last = append(last, models.CloneFormula(f))
+				}
+			}
+			step(fmt.Sprintf("%s-fire-%d", a.Name.Name, i+1),
+				[]models.Formula{pend(), stage(i, false), NNF(w.Condition)},
+				append([]models.Formula{stage(i, true), stage(i+1, false), atom(fired[i], a.Params, false, true, aloc)}, last...))
+			step(fmt.Sprintf("%s-skip-%d", a.Name.Name, i+1),
+				[]models.Formula{pend(), stage(i, false), NNF(newNot(location(w), w.Condition))},
+				append([]models.Formula{stage(i, true), stage(i+1, false), atom(skipped[i], a.Params, false, true, aloc)}, last...))
+		}
+		for i, w := range conds {
+			dels, _ := splitDeletes(conjuncts(models.CloneFormula(w.UnaryNode.Formula)))
+			s := k + i
+			step(fmt.Sprintf("%s-delete-%d", a.Name.Name, i+1),
+				[]models.Formula{pend(), stage(s, false), atom(fired[i], a.Params, false, false, aloc)},
+				append([]models.Formula{stage(s, true), stage(s+1, false)}, dels...))
+			step(fmt.Sprintf("%s-keep-%d", a.Name.Name, i+1),
+				[]models.Formula{pend(), stage(s, false), atom(skipped[i], a.Params, false, false, aloc)},
+				[]models.Formula{stage(s, true), stage(s+1, false)})
+		}
+		for i, w := range conds {
+			_, adds := splitDeletes(conjuncts(models.CloneFormula(w.UnaryNode.Formula)))
+			s := 2*k + i
+			step(fmt.Sprintf("%s-add-%d", a.Name.Name, i+1),
+				[]models.Formula{pend(), stage(s, false), atom(fired[i], a.Params, false, false, aloc)},
+				append([]models.Formula{stage(s, true), stage(s+1, false), atom(fired[i], a.Params, true, true, aloc)}, adds...))
+			step(fmt.Sprintf("%s-skip-add-%d", a.Name.Name, i+1),
+				[]models.Formula{pend(), stage(s, false), atom(skipped[i], a.Params, false, false, aloc)},
+				[]models.Formula{stage(s, true), stage(s+1, false), atom(skipped[i], a.Params, true, true, aloc)})
+		}
+		end := []models.Formula{stage(3*k, true), atom(pending, a.Params, true, true, aloc), atom(normal, nil, false, true, aloc)}
+		for _, f := range uncondAdds {
+			end = append(end, models.CloneFormula(f))
+		}
+		step(a.Name.Name+"-end", []models.Formula{pend(), stage(3*k, false)}, end)
+		return acts, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	nd.Requirements = adjustRequirements(nd, ":conditional-effects")
+	npb.InitialConditions = append(npb.InitialConditions, atom(normal, nil, false, false, loc))
+	npb.Goal = newAnd(location(npb.Goal), append(conjuncts(npb.Goal), atom(normal, nil, false, false, loc)))
+	npb.Requirements = removeRequirements(npb.Requirements, ":conditional-effects")
+	return nd, npb, nil
+}
@@ -0,0 +1,136 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/models"
+	"github.com/guilyx/go-pddl/src/simulator"
+)
+
+const lightsDomain = `(define (domain lights)
+  (:requirements :strips :negative-preconditions :conditional-effects)
+  (:predicates (on-a) (on-b))
+  (:action toggle
+    :parameters ()
+    :effect (and (when (on-a) (and (not (on-a)) (on-b)))
+                 (when (not (on-a)) (on-a)))))
+`
+
+const lightsProblem = `(define (problem lights) (:domain lights)
+  (:init)
+  (:goal (and (on-a) (on-b))))
+`
+
+// planLength returns the length of a shortest plan of the task, or -1
+// when the goal can't be reached.
+func planLength(t *testing.T, d *models.Domain, pb *models.Problem) int {
+	s, err := simulator.NewSimulator(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{s.State().ToString(): true}
+	queue := [][]*simulator.GroundAction{{}}
+	for len(queue) > 0 {
+		plan := queue[0]
+		queue = queue[1:]
+		s.Reset()
+		for _, ga := range plan {
+			if _, err := s.Apply(ga); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ok, err := s.GoalReached()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			return len(plan)
+		}
+		gas, err := s.Applicable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ga := range gas {
+			next, err := s.Successor(ga)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !seen[next.ToString()] {
+				seen[next.ToString()] = true
+				queue = append(queue, append(append([]*simulator.GroundAction{}, plan...), ga))
+			}
+		}
+	}
+	return -1
+}
+
+func hasWhen(d *models.Domain) bool {
+	found := false
+	for _, a := range d.Actions {
+		models.Inspect(a.Effect, func(f models.Formula) bool {
+			if _, ok := f.(*models.WhenNode); ok {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+func TestCompileConditionalEffects(t *testing.T) {
+	tests := []struct {
+		name    string
+		compile func(*models.Domain, *models.Problem) (*models.Domain, *models.Problem, error)
+		actions int
+		length  int
+	}{
+		// 2^2 actions, one per subset of effects that fire.
+		{"exponential", CompileConditionalEffectsExponential, 4, 3},
+		// A start and an end action, and two actions per conditional
+		// effect for each of the evaluation, delete and add stages.
+		{"polynomial", CompileConditionalEffectsPolynomial, 14, 3 * 8},
+	}
+	for _, test := range tests {
+		d, pb := parseTask(t, lightsDomain, lightsProblem)
+		if n := planLength(t, d, pb); n != 3 {
+			t.Fatalf("got a plan of %d steps for the original task, want 3", n)
+		}
+		nd, npb, err := test.compile(d, pb)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(nd.Actions) != test.actions {
+			t.Errorf("%s: got %d actions, want %d", test.name, len(nd.Actions), test.actions)
+		}
+		if hasWhen(nd) || strings.Contains(requirements(nd.Requirements), ":conditional-effects") {
+			t.Errorf("%s: conditional effects are left, with requirements %s", test.name, requirements(nd.Requirements))
+		}
+		if n := planLength(t, nd, npb); n != test.length {
+			t.Errorf("%s: got a plan of %d steps, want %d", test.name, n, test.length)
+		}
+	}
+}
+
+func TestCompileConditionalEffectsNumeric(t *testing.T) {
+	domain := `(define (domain counters)
+  (:requirements :strips :conditional-effects :numeric-fluents)
+  (:predicates (p))
+  (:functions (x) (y))
+  (:action count
+    :parameters ()
+    :effect (and (increase (x) 1) (when (p) (assign (y) (x))))))
+`
+	problem := `(define (problem counters) (:domain counters)
+  (:init (= (x) 0) (= (y) 0))
+  (:goal (> (y) 0)))
+`
+	d, pb := parseTask(t, domain, problem)
+	_, _, err := CompileConditionalEffectsPolynomial(d, pb)
+	if err == nil || !strings.Contains(err.Error(), "reads x") {
+		t.Errorf("got error %v, want (y) reading x rejected", err)
+	}
+	if _, _, err := CompileConditionalEffectsExponential(d, pb); err != nil {
+		t.Errorf("exponential compilation: %v", err)
+	}
+}
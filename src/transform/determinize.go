@@ -0,0 +1,145 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// alternatives returns the deterministic effects an effect may have,
+// one per combination of the choices of its oneof and probabilistic
+// effects. A quantified effect makes the same choice for every
+// instance.
+func alternatives(f models.Formula) ([]models.Formula, error) {
+	switch n := f.(type) {
+	case *models.OneOfNode:
+		alts := []models.Formula{}
+		for _, c := range n.MultiNode.Formula {
+			cs, err := alternatives(c)
+			if err != nil {
+				return nil, err
+			}
+			alts = append(alts, cs...)
+		}
+		return alts, nil
+	case *models.ProbabilisticNode:
+		alts := []models.Formula{}
+		for _, o := range n.Outcomes {
+			cs, err := alternatives(o.Formula)
+			if err != nil {
+				return nil, err
+			}
+			alts = append(alts, cs...)
+		}
+		// The remaining probability is an outcome without effect.
+		rest, err := n.Remainder()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to determinize %s: %v", n.ToString(""), err)
+		}
+		if rest > 0 {
+			alts = append(alts, newAnd(n.Node.Location, nil))
+		}
+		return alts, nil
+	case *models.AndNode:
+		alts := [][]models.Formula{{}}
+		for _, c := range n.MultiNode.Formula {
+			cs, err := alternatives(c)
+			if err != nil {
+				return nil, err
+			}
+			next := [][]models.Formula{}
+			for _, l := range alts {
+				for _, r := range cs {
+					next = append(next, append(append([]models.Formula{}, l...), r))
+				}
+			}
+			alts = next
+		}
+		fs := make([]models.Formula, len(alts))
+		for i, a := range alts {
+			fs[i] = newAnd(n.MultiNode.Node.Location, a)
+		}
+		return fs, nil
+	case *models.WhenNode:
+		cs, err := alternatives(n.UnaryNode.Formula)
+		if err != nil {
+			return nil, err
+		}
+		fs := []models.Formula{}
+		for _, a := range cs {
+			fs = append(fs, &models.WhenNode{
+				Condition: models.CloneFormula(n.Condition),
+				UnaryNode: &models.UnaryNode{
+					Node:    n.UnaryNode.Node,
+					Formula: a,
+				},
+			})
+		}
+		return fs, nil
+	case *models.ForAllNode:
+		cs, err := alternatives(n.QuantNode.UnaryNode.Formula)
+		if err != nil {
+			return nil, err
+		}
+		fs := []models.Formula{}
+		for _, a := range cs {
+			q := models.CloneFormula(n).(*models.ForAllNode)
+			q.QuantNode.UnaryNode.Formula = a
+			fs = append(fs, q)
+		}
+		return fs, nil
+	}
+	return []models.Formula{f}, nil
+}
+
+// Determinize compiles the oneof and probabilistic effects away with
+// the all-outcomes determinization: every action is replaced by one
+// action per outcome of its effect, named like SplitDisjunctions
+// names the split actions. Processes and events can't be split, as
+// all their outcomes would happen at once, nor can subtasks of methods,
+// which are referred to by name.
+func Determinize(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil {
+		return nil, nil, fmt.Errorf("Failed to determinize: domain is nil")
+	}
+	nd := d.Clone()
+	used := structureNames(d)
+	err := mapStructures(nd, func(kind string, a *models.Action) ([]*models.Action, error) {
+		alts, err := alternatives(a.Effect)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to determinize %s %s: %v", kind, a.Name.Name, err)
+		}
+		if len(alts) == 1 {
+			a.Effect = alts[0]
+			return []*models.Action{a}, nil
+		}
+		if kind != "action" {
+			return nil, fmt.Errorf("Failed to determinize %s %s: its effect has several outcomes", kind, a.Name.Name)
+		}
+		if isSubtask(d, pb, a.Name.Name) {
+			return nil, fmt.Errorf("Failed to determinize action %s: its effect has several outcomes and it is a subtask", a.Name.Name)
+		}
+		acts := []*models.Action{}
+		for i, alt := range alts {
+			c := a.Clone()
+			if i > 0 {
+				c.Name = &models.Name{
+					Name:     uniqueName(a.Name.Name, used),
+					Location: a.Name.Location,
+				}
+			}
+			c.Effect = alt
+			acts = append(acts, c)
+		}
+		return acts, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	nd.Requirements = adjustRequirements(nd, ":non-deterministic", ":probabilistic-effects")
+	var npb *models.Problem
+	if pb != nil {
+		npb = pb.Clone()
+	}
+	return nd, npb, nil
+}
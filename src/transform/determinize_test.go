@@ -0,0 +1,73 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+const coinsDomain = `(define (domain coins)
+  (:requirements :strips :negative-preconditions :non-deterministic :probabilistic-effects)
+  (:predicates (heads) (tails) (edge) (tossed) (lucky))
+  (:action toss
+    :parameters ()
+    :precondition (not (tossed))
+    :effect (and (tossed) (oneof (heads) (and (tails) (oneof (edge) (lucky))))))
+  (:action toss-1
+    :parameters ()
+    :precondition (tossed)
+    :effect (probabilistic 0.5 (heads) 0.25 (tails))))
+`
+
+const coinsProblem = `(define (problem coins) (:domain coins)
+  (:init)
+  (:goal (heads)))
+`
+
+func TestOneOf(t *testing.T) {
+	d, _ := parseTask(t, coinsDomain, coinsProblem)
+	eff := conjuncts(d.Actions[0].Effect)
+	oneof, ok := eff[1].(*models.OneOfNode)
+	if !ok || len(oneof.MultiNode.Formula) != 2 {
+		t.Fatalf("got effect %s, want a oneof of two", text(d.Actions[0].Effect))
+	}
+	want := "(and (tossed) (oneof (heads) (and (tails) (oneof (edge) (lucky)))))"
+	if got := text(d.Actions[0].Effect); got != want {
+		t.Errorf("got effect %s, want %s", got, want)
+	}
+}
+
+func TestDeterminize(t *testing.T) {
+	d, pb := parseTask(t, coinsDomain, coinsProblem)
+	nd, _, err := Determinize(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, a := range nd.Actions {
+		got = append(got, a.Name.Name+" "+text(a.Effect))
+	}
+	// The outcomes of toss-1 are named after it, past the names
+	// that are taken, and the remaining probability has no effect.
+	want := []string{
+		"toss (and (tossed) (heads))",
+		"toss-2 (and (tossed) (tails) (edge))",
+		"toss-3 (and (tossed) (tails) (lucky))",
+		"toss-1 (heads)",
+		"toss-1-1 (tails)",
+		"toss-1-2 (and)",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("got actions\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := requirements(nd.Requirements); got != ":strips :negative-preconditions" {
+		t.Errorf("got requirements %s, want :strips :negative-preconditions", got)
+	}
+	if strings.Contains(nd.ToString(), "oneof") || strings.Contains(nd.ToString(), "probabilistic") {
+		t.Errorf("got a non-deterministic domain:\n%s", nd.ToString())
+	}
+	if text(d.Actions[0].Effect) != "(and (tossed) (oneof (heads) (and (tails) (oneof (edge) (lucky)))))" {
+		t.Errorf("the original domain was modified")
+	}
+}
@@ -0,0 +1,117 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// DNF returns the disjunctive normal form of a goal description as a
+// list of conjunctions. Quantified subformulas are kept as atoms;
+// expand them first to split them too.
+func DNF(f models.Formula) [][]models.Formula {
+	return dnf(NNF(f))
+}
+
+func dnf(f models.Formula) [][]models.Formula {
+	switch n := f.(type) {
+	case nil:
+		return [][]models.Formula{{}}
+	case *models.AndNode:
+		clauses := [][]models.Formula{{}}
+		for _, c := range n.MultiNode.Formula {
+			next := [][]models.Formula{}
+			for _, l := range clauses {
+				for _, r := range dnf(c) {
+					clause := append(append([]models.Formula{}, l...), r...)
+					next = append(next, clause)
+				}
+			}
+			clauses = next
+		}
+		return clauses
+	case *models.OrNode:
+		clauses := [][]models.Formula{}
+		for _, c := range n.MultiNode.Formula {
+			clauses = append(clauses, dnf(c)...)
+		}
+		return clauses
+	}
+	return [][]models.Formula{{f}}
+}
+
+// SplitDisjunctions replaces every action whose precondition is a
+// disjunction by one action per disjunct of its disjunctive normal
+// form. The split actions are named after the original one, with a
+// numeric suffix. Methods are split alike, but processes, events and
+// the actions that methods refer to can't be: the copies of the former
+// would all happen when several disjuncts hold, and the latter are
+// referred to by name.
+func SplitDisjunctions(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil {
+		return nil, nil, fmt.Errorf("Failed to split disjunctions: domain is nil")
+	}
+	nd := d.Clone()
+	used := structureNames(d)
+	err := mapStructures(nd, func(kind string, a *models.Action) ([]*models.Action, error) {
+		clauses := DNF(a.Precondition)
+		if len(clauses) == 1 {
+			a.Precondition = NNF(a.Precondition)
+			return []*models.Action{a}, nil
+		}
+		if kind != "action" {
+			return nil, fmt.Errorf("Failed to split disjunctions: %s %s has a disjunctive precondition", kind, a.Name.Name)
+		}
+		if isSubtask(d, pb, a.Name.Name) {
+			return nil, fmt.Errorf("Failed to split disjunctions: action %s has a disjunctive precondition and is a subtask", a.Name.Name)
+		}
+		acts := []*models.Action{}
+		for i, clause := range clauses {
+			c := a.Clone()
+			if i > 0 {
+				c.Name = &models.Name{
+					Name:     uniqueName(a.Name.Name, used),
+					Location: a.Name.Location,
+				}
+			}
+			c.Precondition = newAnd(location(a.Precondition), clause)
+			acts = append(acts, c)
+		}
+		return acts, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	methods := []*models.Method{}
+	usedMethods := map[string]bool{}
+	for _, m := range d.Methods {
+		usedMethods[m.Name.Name] = true
+	}
+	for _, m := range nd.Methods {
+		clauses := DNF(m.Precondition)
+		if len(clauses) == 1 {
+			m.Precondition = NNF(m.Precondition)
+			methods = append(methods, m)
+			continue
+		}
+		for i, clause := range clauses {
+			c := m.Clone()
+			if i > 0 {
+				c.Name = &models.Name{
+					Name:     uniqueName(m.Name.Name, usedMethods),
+					Location: m.Name.Location,
+				}
+			}
+			c.Precondition = newAnd(location(m.Precondition), clause)
+			methods = append(methods, c)
+		}
+	}
+	nd.Methods = methods
+	nd.Requirements = adjustRequirements(nd, ":disjunctive-preconditions")
+	var npb *models.Problem
+	if pb != nil {
+		npb = pb.Clone()
+		npb.Requirements = adjustProblemRequirements(nd, npb)
+	}
+	return nd, npb, nil
+}
@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+// htnDomain returns a hierarchical domain whose drive action has the
+// given precondition.
+func htnDomain(drive string) string {
+	return `(define (domain delivery)
+  (:requirements :strips :typing :negative-preconditions :disjunctive-preconditions
+    :universal-preconditions :hierarchy)
+  (:types truck place)
+  (:predicates (at ?t - truck ?p - place) (road ?a ?b - place) (closed ?p - place))
+  (:task go :parameters (?t - truck ?p - place))
+  (:method direct
+    :parameters (?t - truck ?a ?b - place)
+    :task (go ?t ?b)
+    :precondition (and (not (and (closed ?a) (closed ?b))) (imply (at ?t ?b) (road ?a ?b)))
+    :ordered-subtasks (drive ?t ?a ?b))
+  (:method stay
+    :parameters (?t - truck ?a - place)
+    :task (go ?t ?a)
+    :precondition (forall (?p - place) (not (closed ?p)))
+    :ordered-subtasks ())
+  (:action drive
+    :parameters (?t - truck ?a ?b - place)
+    :precondition ` + drive + `
+    :effect (and (not (at ?t ?a)) (at ?t ?b))))
+`
+}
+
+const htnProblem = `(define (problem delivery) (:domain delivery)
+  (:objects t - truck p1 p2 - place)
+  (:htn :ordered-subtasks (go t p2))
+  (:init (at t p1) (road p1 p2)))
+`
+
+func TestMethodPreconditions(t *testing.T) {
+	d, pb := parseTask(t, htnDomain("(and (at ?t ?a) (road ?a ?b))"), htnProblem)
+	nd, _, err := ToNNF(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(and (or (not (closed ?a)) (not (closed ?b))) (or (not (at ?t ?b)) (road ?a ?b)))"
+	if got := text(nd.Methods[0].Precondition); got != want {
+		t.Errorf("got method precondition %s, want %s", got, want)
+	}
+	nd, _, err = ExpandQuantifiers(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "(and (not (closed p1)) (not (closed p2)))"
+	if got := text(nd.Methods[1].Precondition); got != want {
+		t.Errorf("got method precondition %s, want %s", got, want)
+	}
+	// The methods still need negations and disjunctions.
+	if got := requirements(nd.Requirements); !strings.Contains(got, ":negative-preconditions :disjunctive-preconditions") {
+		t.Errorf("got requirements %s", got)
+	}
+	nd, _, err = CompileNegativePreconditions(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "(forall (?p - place) (not-closed ?p))"
+	if got := text(nd.Methods[1].Precondition); got != want {
+		t.Errorf("got method precondition %s, want %s", got, want)
+	}
+	if got := requirements(nd.Requirements); strings.Contains(got, ":negative-preconditions") {
+		t.Errorf("got requirements %s", got)
+	}
+}
+
+func TestSplitMethods(t *testing.T) {
+	d, pb := parseTask(t, htnDomain("(and (at ?t ?a) (road ?a ?b))"), htnProblem)
+	nd, _, err := SplitDisjunctions(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// direct has four disjuncts, stay none.
+	names := []string{}
+	for _, m := range nd.Methods {
+		names = append(names, m.Name.Name)
+	}
+	if got := strings.Join(names, " "); got != "direct direct-1 direct-2 direct-3 stay" {
+		t.Errorf("got methods %s", got)
+	}
+	want := "(and (not (closed ?b)) (road ?a ?b))"
+	if got := text(nd.Methods[3].Precondition); got != want {
+		t.Errorf("got method precondition %s, want %s", got, want)
+	}
+	d, pb = parseTask(t, htnDomain("(or (at ?t ?a) (road ?a ?b))"), htnProblem)
+	if _, _, err := SplitDisjunctions(d, pb); err == nil || !strings.Contains(err.Error(), "action drive") {
+		t.Errorf("splitting the subtask drive: got error %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// negatedPredicates returns the predicates appearing in a negative
+// literal of the formula.
+func negatedPredicates(f models.Formula, found map[string]bool) {
+	models.Inspect(f, func(f models.Formula) bool {
+		if lit, ok := f.(*models.LiteralNode); ok && lit.Negative && lit.Predicate.Name != "=" {
+			found[lit.Predicate.Name] = true
+		}
+		return true
+	})
+}
+
+// groundings returns every tuple of objects matching the parameters.
+func groundings(params []*models.TypedEntry, u models.Universe) [][]string {
+	out := [][]string{{}}
+	for _, te := range params {
+		next := [][]string{}
+		for _, args := range out {
+			for _, o := range u.OfTypes(te.Types) {
+				next = append(next, append(append([]string{}, args...), o))
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+// CompileNegativePreconditions replaces every predicate p appearing
+// negatively in a precondition, a condition or the goal by a
+// complementary not-p predicate. Every effect on p is mirrored on
+// not-p, and not-p holds initially for the atoms of p that don't.
+func CompileNegativePreconditions(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil || pb == nil {
+		return nil, nil, fmt.Errorf("Failed to compile negative preconditions: domain or problem is nil")
+	}
+	nd, npb, err := ToNNF(d, pb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to compile negative preconditions: %v", err)
+	}
+	nd.Analyze()
+
+	negated := map[string]bool{}
+	for _, a := range nd.Structures() {
+		negatedPredicates(a.Precondition, negated)
+		models.Inspect(a.Effect, func(f models.Formula) bool {
+			if w, ok := f.(*models.WhenNode); ok {
+				negatedPredicates(w.Condition, negated)
+			}
+			return true
+		})
+	}
+	for _, m := range nd.Methods {
+		negatedPredicates(m.Precondition, negated)
+	}
+	negatedPredicates(npb.Goal, negated)
+
+	used := map[string]bool{}
+	for _, p := range nd.Predicates {
+		used[p.Name.Name] = true
+	}
+	complements := map[string]string{}
+	preds := []*models.Predicate{}
+	for _, p := range nd.Predicates {
+		if !negated[p.Name.Name] {
+			continue
+		}
+		complements[p.Name.Name] = uniqueName("not-"+p.Name.Name, used)
+		preds = append(preds, p)
+		nd.Predicates = append(nd.Predicates, &models.Predicate{
+			Name: &models.Name{
+				Name:     complements[p.Name.Name],
+				Location: p.Name.Location,
+			},
+			Parameters: models.CloneTypedEntries(p.Parameters),
+			PosEffect:  p.NegEffect,
+			NegEffect:  p.PosEffect,
+		})
+	}
+
+	// Negative literals become positive literals over the complement.
+	positive := func(f models.Formula) models.Formula {
+		return models.Rewrite(f, func(f models.Formula) models.Formula {
+			lit, ok := f.(*models.LiteralNode)
+			if !ok || !lit.Negative {
+				return f
+			}
+			if c, ok := complements[lit.Predicate.Name]; ok {
+				return newLiteral(lit.Node.Location, c, false, false, lit.Terms)
+			}
+			return f
+		})
+	}
+	// Effects on p get their mirror on the complement.
+	mirror := func(f models.Formula) models.Formula {
+		return models.Rewrite(f, func(f models.Formula) models.Formula {
+			switch n := f.(type) {
+			case *models.WhenNode:
+				n.Condition = positive(n.Condition)
+			case *models.LiteralNode:
+				c, ok := complements[n.Predicate.Name]
+				if !ok || !n.IsEffect {
+					return f
+				}
+				loc := n.Node.Location
+				return newAnd(loc, []models.Formula{
+					n,
+					newLiteral(loc, c, !n.Negative, true, models.CloneTerms(n.Terms)),
+				})
+			case *models.AndNode:
+				// Flatten the conjunctions introduced above.
+				return newAnd(n.MultiNode.Node.Location, n.MultiNode.Formula)
+			}
+			return f
+		})
+	}
+	for _, a := range nd.Structures() {
+		a.Precondition = positive(a.Precondition)
+		a.Effect = mirror(a.Effect)
+	}
+	for _, m := range nd.Methods {
+		m.Precondition = positive(m.Precondition)
+	}
+	nd.Requirements = adjustRequirements(nd, ":negative-preconditions")
+
+	s, err := pb.InitialState()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to compile negative preconditions: %v", err)
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to compile negative preconditions: %v", err)
+	}
+	for _, p := range preds {
+		for _, args := range groundings(p.Parameters, u) {
+			if s.Holds(p.Name.Name, args) {
+				continue
+			}
+			terms := []*models.Term{}
+			for _, a := range args {
+				terms = append(terms, &models.Term{
+					Name: &models.Name{
+						Name:     a,
+						Location: pb.Name.Location,
+					},
+				})
+			}
+			npb.InitialConditions = append(npb.InitialConditions, newLiteral(pb.Name.Location, complements[p.Name.Name], false, false, terms))
+		}
+	}
+	npb.Goal = positive(npb.Goal)
+	npb.Requirements = removeRequirements(npb.Requirements, ":negative-preconditions")
+	return nd, npb, nil
+}
@@ -0,0 +1,104 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+const doorsDomain = `(define (domain doors)
+  (:requirements :strips :typing :negative-preconditions)
+  (:types door)
+  (:predicates (open ?d - door) (locked ?d - door) (passed))
+  (:action unlock
+    :parameters (?d - door)
+    :precondition (locked ?d)
+    :effect (not (locked ?d)))
+  (:action open
+    :parameters (?d - door)
+    :precondition (and (not (locked ?d)) (not (open ?d)))
+    :effect (open ?d))
+  (:action pass
+    :parameters (?d - door)
+    :precondition (open ?d)
+    :effect (passed)))
+`
+
+const doorsProblem = `(define (problem doors) (:domain doors)
+  (:objects front back - door)
+  (:init (locked front))
+  (:goal (and (passed) (not (open back)))))
+`
+
+func hasNegation(d *models.Domain, pb *models.Problem) bool {
+	found := map[string]bool{}
+	for _, a := range d.Actions {
+		negatedPredicates(a.Precondition, found)
+	}
+	negatedPredicates(pb.Goal, found)
+	return len(found) > 0
+}
+
+func TestCompileNegativePreconditions(t *testing.T) {
+	d, pb := parseTask(t, doorsDomain, doorsProblem)
+	nd, npb, err := CompileNegativePreconditions(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasNegation(nd, npb) || strings.Contains(requirements(nd.Requirements), ":negative-preconditions") {
+		t.Errorf("negative preconditions are left, with requirements %s", requirements(nd.Requirements))
+	}
+	want := "(and (not-locked ?d) (not-open ?d))"
+	if got := text(nd.Actions[1].Precondition); got != want {
+		t.Errorf("got precondition %s, want %s", got, want)
+	}
+	want = "(and (not (locked ?d)) (not-locked ?d))"
+	if got := text(nd.Actions[0].Effect); got != want {
+		t.Errorf("got effect %s, want %s", got, want)
+	}
+	init := []string{}
+	for _, f := range npb.InitialConditions {
+		init = append(init, text(f))
+	}
+	want = "(locked front) (not-open back) (not-open front) (not-locked back)"
+	if got := strings.Join(init, " "); got != want {
+		t.Errorf("got initial facts %s, want %s", got, want)
+	}
+	if planLength(t, d, pb) != 3 || planLength(t, nd, npb) != 3 {
+		t.Errorf("the compiled task has a different plan length")
+	}
+}
+
+const swapDomain = `(define (domain swap)
+  (:requirements :strips :equality :negative-preconditions)
+  (:predicates (at ?x) (done))
+  (:action move
+    :parameters (?from ?to)
+    :precondition (and (at ?from) (not (at ?to)) (not (= ?from ?to)))
+    :effect (and (not (at ?from)) (at ?to) (done))))
+`
+
+const swapProblem = `(define (problem swap) (:domain swap)
+  (:objects a b)
+  (:init (at a))
+  (:goal (done)))
+`
+
+func TestCompileNegatedEquality(t *testing.T) {
+	d, pb := parseTask(t, swapDomain, swapProblem)
+	nd, npb, err := CompileNegativePreconditions(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(and (at ?from) (not-at ?to) (not (= ?from ?to)))"
+	if got := text(nd.Actions[0].Precondition); got != want {
+		t.Errorf("got precondition %s, want %s", got, want)
+	}
+	if got := requirements(nd.Requirements); got != ":strips :equality" {
+		t.Errorf("got requirements %s, want :strips :equality", got)
+	}
+	if planLength(t, nd, npb) != 1 {
+		t.Errorf("got no one-step plan")
+	}
+}
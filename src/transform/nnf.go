@@ -0,0 +1,157 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+var (
+	// negatedComparisons maps every comparison but "=" to its
+	// complement.
+	negatedComparisons = map[string]string{
+		"<":  ">=",
+		"<=": ">",
+		">=": "<",
+		">":  "<=",
+	}
+)
+
+// NNF returns the negation normal form of a goal description: imply
+// is eliminated and negations only apply to atoms.
+func NNF(f models.Formula) models.Formula {
+	return nnf(f, false)
+}
+
+func nnf(f models.Formula, negated bool) models.Formula {
+	loc := location(f)
+	switch n := f.(type) {
+	case nil:
+		return nil
+	case *models.LiteralNode:
+		c := models.CloneFormula(n).(*models.LiteralNode)
+		if negated {
+			c.Negative = !c.Negative
+		}
+		return c
+	case *models.AndNode, *models.OrNode:
+		fs := []models.Formula{}
+		for _, c := range models.Children(n) {
+			fs = append(fs, nnf(c, negated))
+		}
+		if _, isAnd := n.(*models.AndNode); isAnd != negated {
+			return newAnd(loc, fs)
+		}
+		return newOr(loc, fs)
+	case *models.NotNode:
+		return nnf(n.UnaryNode.Formula, !negated)
+	case *models.ImplyNode:
+		l, r := n.BinaryNode.Left, n.BinaryNode.Right
+		if negated {
+			return newAnd(loc, []models.Formula{nnf(l, false), nnf(r, true)})
+		}
+		return newOr(loc, []models.Formula{nnf(l, true), nnf(r, false)})
+	case *models.ForAllNode, *models.ExistsNode:
+		var q *models.QuantNode
+		_, isForAll := n.(*models.ForAllNode)
+		if isForAll {
+			q = n.(*models.ForAllNode).QuantNode
+		} else {
+			q = n.(*models.ExistsNode).QuantNode
+		}
+		body := &models.QuantNode{
+			Variables: models.CloneTypedEntries(q.Variables),
+			UnaryNode: &models.UnaryNode{
+				Node:    q.UnaryNode.Node,
+				Formula: nnf(q.UnaryNode.Formula, negated),
+			},
+		}
+		if isForAll != negated {
+			return &models.ForAllNode{
+				QuantNode: body,
+			}
+		}
+		return &models.ExistsNode{
+			QuantNode: body,
+		}
+	case *models.CompareNode:
+		c := models.CloneFormula(n).(*models.CompareNode)
+		if !negated {
+			return c
+		}
+		if op, ok := negatedComparisons[c.Operation.Name]; ok {
+			c.Operation = &models.Name{
+				Name:     op,
+				Location: c.Operation.Location,
+			}
+			return c
+		}
+		return newNot(loc, c)
+	}
+	c := models.CloneFormula(f)
+	if negated {
+		return newNot(loc, c)
+	}
+	return c
+}
+
+// nnfEffect puts the conditions of the conditional effects in
+// negation normal form.
+func nnfEffect(f models.Formula) models.Formula {
+	return models.Rewrite(models.CloneFormula(f), func(f models.Formula) models.Formula {
+		if w, ok := f.(*models.WhenNode); ok {
+			w.Condition = NNF(w.Condition)
+		}
+		return f
+	})
+}
+
+// ToNNF puts every precondition, conditional effect condition and
+// the goal in negation normal form, declaring :negative-preconditions
+// when negated atoms appear. The problem may be nil.
+func ToNNF(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil {
+		return nil, nil, fmt.Errorf("Failed to compute negation normal form: domain is nil")
+	}
+	nd := d.Clone()
+	for _, a := range nd.Structures() {
+		a.Precondition = NNF(a.Precondition)
+		a.Effect = nnfEffect(a.Effect)
+	}
+	for _, m := range nd.Methods {
+		m.Precondition = NNF(m.Precondition)
+	}
+	nd.Requirements = adjustRequirements(nd)
+	var npb *models.Problem
+	if pb != nil {
+		npb = pb.Clone()
+		npb.Goal = NNF(npb.Goal)
+		npb.Requirements = adjustProblemRequirements(nd, npb)
+	}
+	return nd, npb, nil
+}
+
+// EliminateImplications rewrites every (imply a b) as (or (not a) b),
+// declaring :negative-preconditions for the negations.
+func EliminateImplications(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil {
+		return nil, nil, fmt.Errorf("Failed to eliminate implications: domain is nil")
+	}
+	rw := func(f models.Formula) models.Formula {
+		if n, ok := f.(*models.ImplyNode); ok {
+			loc := location(n)
+			return newOr(loc, []models.Formula{newNot(loc, n.BinaryNode.Left), n.BinaryNode.Right})
+		}
+		return f
+	}
+	nd := d.Clone()
+	models.RewriteDomain(nd, rw)
+	nd.Requirements = adjustRequirements(nd)
+	var npb *models.Problem
+	if pb != nil {
+		npb = pb.Clone()
+		models.RewriteProblem(npb, rw)
+		npb.Requirements = adjustProblemRequirements(nd, npb)
+	}
+	return nd, npb, nil
+}
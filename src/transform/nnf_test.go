@@ -0,0 +1,214 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+	"github.com/guilyx/go-pddl/src/parser"
+)
+
+const normalDomain = `(define (domain normal)
+  (:requirements :strips :typing :disjunctive-preconditions :quantified-preconditions)
+  (:types item)
+  (:predicates (p ?x - item) (q ?x - item) (r) (s))
+  (:action imply
+    :parameters (?x - item)
+    :precondition (imply (p ?x) (q ?x))
+    :effect (r))
+  (:action either
+    :parameters (?x - item)
+    :precondition (and (or (p ?x) (q ?x)) (or (r) (s)))
+    :effect (s))
+  (:action every
+    :parameters ()
+    :precondition (forall (?x - item) (p ?x))
+    :effect (r)))
+`
+
+const normalProblem = `(define (problem three) (:domain normal)
+  (:objects a b c - item)
+  (:init (p a))
+  (:goal (not (and (r) (exists (?x - item) (q ?x))))))
+`
+
+func parseTask(t *testing.T, domain string, problem string) (*models.Domain, *models.Problem) {
+	d, pb, err := parser.ParseTexts(&config.Config{}, domain, problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, pb
+}
+
+// text writes a formula on one line.
+func text(f models.Formula) string {
+	return strings.Join(strings.Fields(f.ToString("")), " ")
+}
+
+func requirements(reqs []*models.Name) string {
+	names := []string{}
+	for _, r := range reqs {
+		names = append(names, r.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestToNNF(t *testing.T) {
+	d, pb := parseTask(t, normalDomain, normalProblem)
+	nd, npb, err := ToNNF(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(or (not (p ?x)) (q ?x))"
+	if got := text(nd.Actions[0].Precondition); got != want {
+		t.Errorf("got precondition %s, want %s", got, want)
+	}
+	want = "(or (not (r)) (forall (?x - item) (not (q ?x))))"
+	if got := text(npb.Goal); got != want {
+		t.Errorf("got goal %s, want %s", got, want)
+	}
+	if !strings.Contains(requirements(nd.Requirements), ":negative-preconditions") {
+		t.Errorf("got domain requirements %s, want :negative-preconditions", requirements(nd.Requirements))
+	}
+	if requirements(npb.Requirements) != "" {
+		t.Errorf("got problem requirements %s, declared by the domain", requirements(npb.Requirements))
+	}
+	if text(d.Actions[0].Precondition) != "(imply (p ?x) (q ?x))" {
+		t.Errorf("the original domain was modified")
+	}
+}
+
+func TestEliminateImplications(t *testing.T) {
+	d, pb := parseTask(t, normalDomain, normalProblem)
+	nd, _, err := EliminateImplications(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(or (not (p ?x)) (q ?x))"
+	if got := text(nd.Actions[0].Precondition); got != want {
+		t.Errorf("got precondition %s, want %s", got, want)
+	}
+	if !strings.Contains(requirements(nd.Requirements), ":negative-preconditions") {
+		t.Errorf("got domain requirements %s, want :negative-preconditions", requirements(nd.Requirements))
+	}
+}
+
+func TestDNF(t *testing.T) {
+	d, _ := parseTask(t, normalDomain, normalProblem)
+	clauses := DNF(d.Actions[1].Precondition)
+	if len(clauses) != 4 {
+		t.Fatalf("got %d clauses, want 4", len(clauses))
+	}
+	got := []string{}
+	for _, c := range clauses {
+		lits := []string{}
+		for _, f := range c {
+			lits = append(lits, text(f))
+		}
+		got = append(got, strings.Join(lits, " "))
+	}
+	want := "(p ?x) (r); (p ?x) (s); (q ?x) (r); (q ?x) (s)"
+	if strings.Join(got, "; ") != want {
+		t.Errorf("got clauses %s, want %s", strings.Join(got, "; "), want)
+	}
+}
+
+func TestSplitDisjunctions(t *testing.T) {
+	d, pb := parseTask(t, normalDomain, normalProblem)
+	nd, _, err := SplitDisjunctions(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// imply splits in two, either in four and every is kept.
+	if len(nd.Actions) != 7 {
+		t.Errorf("got %d actions, want 7", len(nd.Actions))
+	}
+	names := map[string]bool{}
+	for _, a := range nd.Actions {
+		if names[a.Name.Name] {
+			t.Errorf("action %s is declared twice", a.Name.Name)
+		}
+		names[a.Name.Name] = true
+	}
+}
+
+func TestExpandQuantifiers(t *testing.T) {
+	d, pb := parseTask(t, normalDomain, normalProblem)
+	nd, npb, err := ExpandQuantifiers(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(and (p a) (p b) (p c))"
+	if got := text(nd.Actions[2].Precondition); got != want {
+		t.Errorf("got precondition %s, want %s", got, want)
+	}
+	want = "(not (and (r) (or (q a) (q b) (q c))))"
+	if got := text(npb.Goal); got != want {
+		t.Errorf("got goal %s, want %s", got, want)
+	}
+	if strings.Contains(requirements(nd.Requirements), ":quantified-preconditions") {
+		t.Errorf("got domain requirements %s", requirements(nd.Requirements))
+	}
+}
+
+const existsDomain = `(define (domain exists)
+  (:requirements :strips :typing :existential-preconditions)
+  (:types item)
+  (:predicates (p ?x - item) (q ?x - item))
+  (:action some
+    :parameters ()
+    :precondition (exists (?x - item) (p ?x))
+    :effect (q a)))
+`
+
+const existsProblem = `(define (problem two) (:domain exists)
+  (:requirements :universal-preconditions)
+  (:objects a b - item)
+  (:init (p a))
+  (:goal (forall (?x - item) (not (q ?x)))))
+`
+
+// printedRequirements returns the requirements section as printed,
+// on one line.
+func printedRequirements(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	i := strings.Index(s, "(:requirements")
+	if i < 0 {
+		return ""
+	}
+	return s[i : i+strings.Index(s[i:], ")")+1]
+}
+
+func TestExpandQuantifiersRequirements(t *testing.T) {
+	d, pb := parseTask(t, existsDomain, existsProblem)
+	nd, npb, err := ExpandQuantifiers(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(:requirements :strips :typing :disjunctive-preconditions)"
+	if got := printedRequirements(nd.ToString()); got != want {
+		t.Errorf("got domain %s, want %s", got, want)
+	}
+	want = "(:requirements :negative-preconditions)"
+	if got := printedRequirements(npb.ToString()); got != want {
+		t.Errorf("got problem %s, want %s", got, want)
+	}
+}
+
+func TestSplitDisjunctionsRequirements(t *testing.T) {
+	d, pb := parseTask(t, normalDomain, normalProblem)
+	nd, npb, err := SplitDisjunctions(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The imply splits into (not (p ?x)) and (q ?x), and the goal
+	// keeps its negation.
+	want := "(:requirements :strips :typing :quantified-preconditions :negative-preconditions)"
+	if got := printedRequirements(nd.ToString()); got != want {
+		t.Errorf("got domain %s, want %s", got, want)
+	}
+	if got := printedRequirements(npb.ToString()); got != "" {
+		t.Errorf("got problem %s, declared by the domain", got)
+	}
+}
@@ -0,0 +1,197 @@
+package transform
+
+import (
+	"strconv"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// location returns the location of the node, when it has one.
+func location(f models.Formula) *models.Location {
+	var n *models.Node
+	switch f := f.(type) {
+	case *models.LiteralNode:
+		n = f.Node
+	case *models.AndNode:
+		n = &f.MultiNode.Node
+	case *models.OrNode:
+		n = &f.MultiNode.Node
+	case *models.OneOfNode:
+		n = &f.MultiNode.Node
+	case *models.NotNode:
+		n = f.UnaryNode.Node
+	case *models.ImplyNode:
+		n = &f.BinaryNode.Node
+	case *models.ForAllNode:
+		n = f.QuantNode.UnaryNode.Node
+	case *models.ExistsNode:
+		n = f.QuantNode.UnaryNode.Node
+	case *models.WhenNode:
+		n = f.UnaryNode.Node
+	case *models.TimedInitNode:
+		n = f.Node
+	case *models.ProbabilisticNode:
+		n = f.Node
+	case *models.AssignNode:
+		n = f.Node
+	case *models.CompareNode:
+		n = f.Node
+	}
+	if n == nil {
+		return nil
+	}
+	return n.Location
+}
+
+// newAnd returns the conjunction of the formulas, flattening nested
+// conjunctions.
+func newAnd(loc *models.Location, fs []models.Formula) models.Formula {
+	flat := []models.Formula{}
+	for _, f := range fs {
+		if a, ok := f.(*models.AndNode); ok {
+			flat = append(flat, a.MultiNode.Formula...)
+			continue
+		}
+		if f != nil {
+			flat = append(flat, f)
+		}
+	}
+	return &models.AndNode{
+		MultiNode: &models.MultiNode{
+			Node: models.Node{
+				Location: loc,
+			},
+			Formula: flat,
+		},
+	}
+}
+
+// newOr returns the disjunction of the formulas, flattening nested
+// disjunctions.
+func newOr(loc *models.Location, fs []models.Formula) models.Formula {
+	flat := []models.Formula{}
+	for _, f := range fs {
+		if o, ok := f.(*models.OrNode); ok {
+			flat = append(flat, o.MultiNode.Formula...)
+			continue
+		}
+		flat = append(flat, f)
+	}
+	return &models.OrNode{
+		MultiNode: &models.MultiNode{
+			Node: models.Node{
+				Location: loc,
+			},
+			Formula: flat,
+		},
+	}
+}
+
+func newNot(loc *models.Location, f models.Formula) models.Formula {
+	return &models.NotNode{
+		UnaryNode: &models.UnaryNode{
+			Node: &models.Node{
+				Location: loc,
+			},
+			Formula: f,
+		},
+	}
+}
+
+func newLiteral(loc *models.Location, pred string, negative bool, effect bool, terms []*models.Term) *models.LiteralNode {
+	return &models.LiteralNode{
+		Node: &models.Node{
+			Location: loc,
+		},
+		Predicate: &models.Name{
+			Name:     pred,
+			Location: loc,
+		},
+		Negative: negative,
+		IsEffect: effect,
+		Terms:    terms,
+	}
+}
+
+// conjuncts returns the members of a conjunction, or the formula
+// itself.
+func conjuncts(f models.Formula) []models.Formula {
+	switch n := f.(type) {
+	case nil:
+		return nil
+	case *models.AndNode:
+		return n.MultiNode.Formula
+	}
+	return []models.Formula{f}
+}
+
+// uniqueName returns name, or name followed by the first free
+// numeric suffix.
+func uniqueName(name string, used map[string]bool) string {
+	n := name
+	for i := 1; used[n]; i++ {
+		n = name + "-" + strconv.Itoa(i)
+	}
+	used[n] = true
+	return n
+}
+
+// structureNames returns the names of the actions, processes and
+// events of the domain.
+func structureNames(d *models.Domain) map[string]bool {
+	used := map[string]bool{}
+	for _, a := range d.Structures() {
+		used[a.Name.Name] = true
+	}
+	return used
+}
+
+// mapStructures replaces every action, process and event of the
+// domain by the structures fn returns for it. The kind is "action",
+// "process" or "event".
+func mapStructures(d *models.Domain, fn func(kind string, a *models.Action) ([]*models.Action, error)) error {
+	lists := []struct {
+		kind string
+		as   *[]*models.Action
+	}{
+		{"action", &d.Actions},
+		{"process", &d.Processes},
+		{"event", &d.Events},
+	}
+	for _, l := range lists {
+		out := []*models.Action{}
+		for _, a := range *l.as {
+			as, err := fn(l.kind, a)
+			if err != nil {
+				return err
+			}
+			out = append(out, as...)
+		}
+		*l.as = out
+	}
+	return nil
+}
+
+// isSubtask tells whether a method of the domain or the initial task
+// network of the problem refers to the action by name, in which case
+// the action can't be split.
+func isSubtask(d *models.Domain, pb *models.Problem, action string) bool {
+	networks := []*models.TaskNetwork{}
+	for _, m := range d.Methods {
+		networks = append(networks, m.Network)
+	}
+	if pb != nil {
+		networks = append(networks, pb.Htn)
+	}
+	for _, tn := range networks {
+		if tn == nil {
+			continue
+		}
+		for _, st := range tn.Subtasks {
+			if st.Task.Name == action {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// Substitute returns a copy of the formula where the free variables
+// are replaced by their bindings.
+func Substitute(f models.Formula, b models.Bindings) models.Formula {
+	return substitute(models.CloneFormula(f), b)
+}
+
+// substitute works in place on a formula the caller owns.
+func substitute(f models.Formula, b models.Bindings) models.Formula {
+	models.Inspect(f, func(f models.Formula) bool {
+		switch n := f.(type) {
+		case *models.LiteralNode:
+			substituteTerms(n.Terms, b)
+		case *models.AssignNode:
+			substituteTerms(n.AssignedTo.Terms, b)
+			if n.FunctionInit != nil {
+				substituteTerms(n.FunctionInit.Terms, b)
+			}
+			substituteExpression(n.Expression, b)
+			if n.Term != nil {
+				substituteTerms([]*models.Term{n.Term}, b)
+			}
+		case *models.CompareNode:
+			substituteExpression(n.Left, b)
+			substituteExpression(n.Right, b)
+		case *models.ForAllNode, *models.ExistsNode:
+			vars := quantified(n).Variables
+			inner := models.Bindings{}
+			for k, v := range b {
+				inner[k] = v
+			}
+			for _, v := range vars {
+				delete(inner, v.Name.Name)
+			}
+			if len(inner) < len(b) {
+				// Variables shadowed by the quantifier
+				// keep their meaning in its body.
+				substitute(quantified(n).UnaryNode.Formula, inner)
+				return false
+			}
+		}
+		return true
+	})
+	return f
+}
+
+func substituteTerms(ts []*models.Term, b models.Bindings) {
+	for _, t := range ts {
+		if t.Function != nil {
+			substituteTerms(t.Function.Terms, b)
+			continue
+		}
+		if v, ok := b[t.Name.Name]; ok {
+			t.Name = &models.Name{
+				Name:     v,
+				Location: t.Name.Location,
+			}
+			t.IsVariable = false
+		}
+	}
+}
+
+func substituteExpression(e *models.NumericExpression, b models.Bindings) {
+	if e == nil {
+		return
+	}
+	if e.FunctionInit != nil {
+		substituteTerms(e.FunctionInit.Terms, b)
+	}
+	for _, o := range e.Operands {
+		substituteExpression(o, b)
+	}
+}
+
+func quantified(f models.Formula) *models.QuantNode {
+	switch n := f.(type) {
+	case *models.ForAllNode:
+		return n.QuantNode
+	case *models.ExistsNode:
+		return n.QuantNode
+	}
+	return nil
+}
+
+// Expand replaces the quantifiers of the formula by the conjunction
+// or disjunction of their instances over the objects of the universe.
+func Expand(f models.Formula, u models.Universe) models.Formula {
+	env := &models.Env{
+		Universe: u,
+	}
+	return models.Rewrite(models.CloneFormula(f), func(f models.Formula) models.Formula {
+		switch n := f.(type) {
+		case *models.AndNode:
+			return newAnd(location(n), n.MultiNode.Formula)
+		case *models.OrNode:
+			return newOr(location(n), n.MultiNode.Formula)
+		}
+		q := quantified(f)
+		if q == nil {
+			return f
+		}
+		instances := []models.Formula{}
+		env.Each(q.Variables, models.Bindings{}, func(b models.Bindings) (bool, error) {
+			instances = append(instances, Substitute(q.UnaryNode.Formula, b))
+			return false, nil
+		})
+		if _, ok := f.(*models.ExistsNode); ok {
+			return newOr(location(f), instances)
+		}
+		return newAnd(location(f), instances)
+	})
+}
+
+// ExpandQuantifiers expands every forall and exists of the domain
+// and the goal over the finite set of objects of the problem.
+func ExpandQuantifiers(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil || pb == nil {
+		return nil, nil, fmt.Errorf("Failed to expand quantifiers: domain or problem is nil")
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to expand quantifiers: %v", err)
+	}
+	nd := d.Clone()
+	for _, a := range nd.Structures() {
+		a.Precondition = Expand(a.Precondition, u)
+		a.Effect = Expand(a.Effect, u)
+	}
+	for _, m := range nd.Methods {
+		m.Precondition = Expand(m.Precondition, u)
+	}
+	nd.Requirements = adjustRequirements(nd,
+		":quantified-preconditions", ":universal-preconditions", ":existential-preconditions")
+	npb := pb.Clone()
+	npb.Goal = Expand(npb.Goal, u)
+	npb.Requirements = removeRequirements(npb.Requirements,
+		":quantified-preconditions", ":universal-preconditions", ":existential-preconditions")
+	npb.Requirements = adjustProblemRequirements(nd, npb)
+	return nd, npb, nil
+}
@@ -0,0 +1,172 @@
+package transform
+
+import (
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+var (
+	// impliedReqs maps the requirements that are shorthands to
+	// the requirements they stand for.
+	impliedReqs = map[string][]string{
+		":adl": {
+			":strips",
+			":typing",
+			":negative-preconditions",
+			":disjunctive-preconditions",
+			":equality",
+			":quantified-preconditions",
+			":conditional-effects",
+		},
+		":quantified-preconditions": {
+			":existential-preconditions",
+			":universal-preconditions",
+		},
+		":fluents": {
+			":numeric-fluents",
+			":object-fluents",
+		},
+	}
+)
+
+// removeRequirements drops the requirements from the list. Shorthands
+// covering one of them are replaced by their remaining components.
+func removeRequirements(reqs []*models.Name, removed ...string) []*models.Name {
+	drop := map[string]bool{}
+	for _, r := range removed {
+		drop[r] = true
+	}
+	var expand func(r *models.Name) []*models.Name
+	expand = func(r *models.Name) []*models.Name {
+		if drop[r.Name] {
+			return nil
+		}
+		parts, ok := impliedReqs[r.Name]
+		if !ok || !covers(r.Name, drop) {
+			return []*models.Name{r}
+		}
+		out := []*models.Name{}
+		for _, p := range parts {
+			out = append(out, expand(&models.Name{
+				Name:     p,
+				Location: r.Location,
+			})...)
+		}
+		return out
+	}
+	out := []*models.Name{}
+	seen := map[string]bool{}
+	for _, r := range reqs {
+		for _, e := range expand(r) {
+			if !seen[e.Name] {
+				seen[e.Name] = true
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
+
+// covers tells whether the requirement stands for one of the
+// dropped requirements.
+func covers(req string, drop map[string]bool) bool {
+	for _, p := range impliedReqs[req] {
+		if drop[p] || covers(p, drop) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRequirement tells whether the requirement is declared, directly
+// or through a shorthand.
+func hasRequirement(reqs []*models.Name, req string) bool {
+	for _, r := range reqs {
+		if r.Name == req || covers(r.Name, map[string]bool{req: true}) {
+			return true
+		}
+	}
+	return false
+}
+
+func addRequirement(reqs []*models.Name, req string) []*models.Name {
+	if hasRequirement(reqs, req) {
+		return reqs
+	}
+	return append(reqs, &models.Name{
+		Name:     req,
+		Location: &models.Location{},
+	})
+}
+
+// adjustRequirements drops the compiled away requirements and
+// declares the ones the compiled domain now relies on.
+func adjustRequirements(d *models.Domain, removed ...string) []*models.Name {
+	reqs := removeRequirements(d.Requirements, removed...)
+	negative, disjunctive, conditional := false, false, false
+	for _, a := range d.Structures() {
+		n, o := conditionRequirements(a.Precondition)
+		negative, disjunctive = negative || n, disjunctive || o
+		models.Inspect(a.Effect, func(f models.Formula) bool {
+			if w, ok := f.(*models.WhenNode); ok {
+				conditional = true
+				n, o := conditionRequirements(w.Condition)
+				negative, disjunctive = negative || n, disjunctive || o
+			}
+			return true
+		})
+	}
+	for _, m := range d.Methods {
+		n, o := conditionRequirements(m.Precondition)
+		negative, disjunctive = negative || n, disjunctive || o
+	}
+	if negative {
+		reqs = addRequirement(reqs, ":negative-preconditions")
+	}
+	if disjunctive {
+		reqs = addRequirement(reqs, ":disjunctive-preconditions")
+	}
+	if conditional {
+		reqs = addRequirement(reqs, ":conditional-effects")
+	}
+	return reqs
+}
+
+// adjustProblemRequirements adds the requirements that the goal of the
+// problem needs and the domain doesn't declare.
+func adjustProblemRequirements(d *models.Domain, pb *models.Problem) []*models.Name {
+	reqs := pb.Requirements
+	negative, disjunctive := conditionRequirements(pb.Goal)
+	if negative && !hasRequirement(d.Requirements, ":negative-preconditions") {
+		reqs = addRequirement(reqs, ":negative-preconditions")
+	}
+	if disjunctive && !hasRequirement(d.Requirements, ":disjunctive-preconditions") {
+		reqs = addRequirement(reqs, ":disjunctive-preconditions")
+	}
+	return reqs
+}
+
+// conditionRequirements tells whether the goal description has
+// negations and disjunctions. Negated equalities only need :equality.
+func conditionRequirements(f models.Formula) (negative bool, disjunctive bool) {
+	models.Inspect(f, func(f models.Formula) bool {
+		switch n := f.(type) {
+		case *models.LiteralNode:
+			if n.Negative && !isEquality(n) {
+				negative = true
+			}
+		case *models.NotNode:
+			if !isEquality(n.UnaryNode.Formula) {
+				negative = true
+			}
+		case *models.OrNode, *models.ImplyNode:
+			disjunctive = true
+		}
+		return true
+	})
+	return
+}
+
+func isEquality(f models.Formula) bool {
+	lit, ok := f.(*models.LiteralNode)
+	return ok && lit.Predicate.Name == "="
+}
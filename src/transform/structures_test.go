@@ -0,0 +1,99 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+// tankDomain returns a domain with a process and an event, the event
+// having the given effect.
+func tankDomain(reqs string, event string) string {
+	return `(define (domain tank)
+  (:requirements :strips :negative-preconditions :disjunctive-preconditions
+    :universal-preconditions :conditional-effects :time ` + reqs + `)
+  (:predicates (open ?v) (full) (alarm) (ok ?v))
+  (:process fill
+    :parameters (?v)
+    :precondition (and (open ?v) (not (and (full) (alarm))))
+    :effect (full))
+  (:event overflow
+    :parameters ()
+    :precondition (and (full) (not (alarm)) (forall (?v) (ok ?v)))
+    :effect ` + event + `)
+  (:action close
+    :parameters (?v)
+    :precondition (open ?v)
+    :effect (not (open ?v))))
+`
+}
+
+const tankProblem = `(define (problem tank) (:domain tank)
+  (:objects v1 v2)
+  (:init (open v1) (ok v1) (ok v2))
+  (:goal (alarm)))
+`
+
+func TestStructuresNormalForms(t *testing.T) {
+	d, pb := parseTask(t, tankDomain("", "(alarm)"), tankProblem)
+	nd, _, err := ToNNF(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(and (open ?v) (or (not (full)) (not (alarm))))"
+	if got := text(nd.Processes[0].Precondition); got != want {
+		t.Errorf("got process precondition %s, want %s", got, want)
+	}
+	nd, _, err = ExpandQuantifiers(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "(and (full) (not (alarm)) (ok v1) (ok v2))"
+	if got := text(nd.Events[0].Precondition); got != want {
+		t.Errorf("got event precondition %s, want %s", got, want)
+	}
+	nd, _, err = CompileNegativePreconditions(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "(and (open ?v) (or (not-full) (not-alarm)))"
+	if got := text(nd.Processes[0].Precondition); got != want {
+		t.Errorf("got process precondition %s, want %s", got, want)
+	}
+	if got := text(nd.Events[0].Effect); got != "(and (alarm) (not (not-alarm)))" {
+		t.Errorf("got event effect %s, not mirrored on not-alarm", got)
+	}
+}
+
+func TestStructuresSplits(t *testing.T) {
+	d, pb := parseTask(t, tankDomain("", "(and (alarm) (forall (?v) (when (open ?v) (not (open ?v)))))"), tankProblem)
+	if _, _, err := SplitDisjunctions(d, pb); err == nil || !strings.Contains(err.Error(), "process fill") {
+		t.Errorf("splitting the disjunctive process: got error %v", err)
+	}
+	nd, _, err := CompileConditionalEffectsExponential(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two conditional effects, one per object.
+	if len(nd.Events) != 4 || len(nd.Processes) != 1 || len(nd.Actions) != 1 {
+		t.Errorf("got %d actions, %d processes and %d events, want 1, 1 and 4",
+			len(nd.Actions), len(nd.Processes), len(nd.Events))
+	}
+	if hasWhen(nd) {
+		t.Errorf("conditional effects are left")
+	}
+	if _, _, err := CompileConditionalEffectsPolynomial(d, pb); err == nil || !strings.Contains(err.Error(), "event overflow") {
+		t.Errorf("staging the conditional event: got error %v", err)
+	}
+	d, pb = parseTask(t, tankDomain("", "(alarm)"), tankProblem)
+	nd, _, err = CompileConditionalEffectsPolynomial(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := text(nd.Processes[0].Precondition); !strings.Contains(got, "(normal-mode)") {
+		t.Errorf("got process precondition %s, want it to wait for normal mode", got)
+	}
+	d, pb = parseTask(t, tankDomain(":non-deterministic", "(oneof (alarm) (not (full)))"), tankProblem)
+	if _, _, err := Determinize(d, pb); err == nil || !strings.Contains(err.Error(), "event overflow") {
+		t.Errorf("determinizing the event: got error %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package transform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// typePredicates maps every type but object to the unary predicate
+// standing for it.
+type typePredicates map[string]string
+
+// condition returns the formula stating that the variable has one of
+// the types, or nil when any object does.
+func (tp typePredicates) condition(v *models.Name, ts []*models.TypeName) models.Formula {
+	fs := []models.Formula{}
+	for _, t := range ts {
+		pred, ok := tp[t.Name.Name]
+		if !ok {
+			// Every object is an object.
+			return nil
+		}
+		fs = append(fs, newLiteral(v.Location, pred, false, false, []*models.Term{
+			{
+				Name:       v,
+				IsVariable: true,
+			},
+		}))
+	}
+	switch len(fs) {
+	case 0:
+		return nil
+	case 1:
+		return fs[0]
+	}
+	return newOr(v.Location, fs)
+}
+
+// conditions returns the type conditions of the typed entries, and
+// removes their types.
+func (tp typePredicates) conditions(tes []*models.TypedEntry) []models.Formula {
+	fs := []models.Formula{}
+	for _, te := range tes {
+		if c := tp.condition(te.Name, te.Types); c != nil {
+			fs = append(fs, c)
+		}
+		te.Types = nil
+	}
+	return fs
+}
+
+// guard untypes the parameters and the precondition, and adds the type
+// conditions of the parameters to the precondition.
+func (tp typePredicates) guard(params []*models.TypedEntry, pre models.Formula) models.Formula {
+	conds := tp.conditions(params)
+	loc := location(pre)
+	pre = tp.untypeFormula(pre)
+	if len(conds) == 0 {
+		return pre
+	}
+	return newAnd(loc, append(conds, conjuncts(pre)...))
+}
+
+// untypeFormula guards the quantified variables of the formula with
+// their type predicates: forall bodies become implications, exists
+// bodies conjunctions and forall effects conditional effects.
+func (tp typePredicates) untypeFormula(f models.Formula) models.Formula {
+	return models.Rewrite(f, func(f models.Formula) models.Formula {
+		switch n := f.(type) {
+		case *models.ForAllNode:
+			q := n.QuantNode
+			conds := tp.conditions(q.Variables)
+			if len(conds) == 0 {
+				return f
+			}
+			loc := location(n)
+			if n.IsEffect {
+				q.UnaryNode.Formula = guardEffect(q.UnaryNode.Formula, conds, loc)
+				return f
+			}
+			q.UnaryNode.Formula = &models.ImplyNode{
+				BinaryNode: &models.BinaryNode{
+					Node: models.Node{
+						Location: loc,
+					},
+					Left:  newAnd(loc, conds),
+					Right: q.UnaryNode.Formula,
+				},
+			}
+		case *models.ExistsNode:
+			q := n.QuantNode
+			conds := tp.conditions(q.Variables)
+			if len(conds) > 0 {
+				q.UnaryNode.Formula = newAnd(location(n), append(conds, q.UnaryNode.Formula))
+			}
+		}
+		return f
+	})
+}
+
+// guardEffect makes the effect conditional on the guards. Conditional
+// effects can't be nested, so the guards are added to the conditions
+// of the existing ones.
+func guardEffect(eff models.Formula, guards []models.Formula, loc *models.Location) models.Formula {
+	plain := []models.Formula{}
+	out := []models.Formula{}
+	for _, e := range conjuncts(eff) {
+		w, ok := e.(*models.WhenNode)
+		if !ok {
+			plain = append(plain, e)
+			continue
+		}
+		cond := append(models.CloneFormula(newAnd(loc, guards)).(*models.AndNode).MultiNode.Formula, w.Condition)
+		w.Condition = newAnd(location(w), cond)
+		out = append(out, w)
+	}
+	if len(plain) > 0 {
+		out = append([]models.Formula{&models.WhenNode{
+			Condition: newAnd(loc, guards),
+			UnaryNode: &models.UnaryNode{
+				Node: &models.Node{
+					Location: loc,
+				},
+				Formula: newAnd(loc, plain),
+			},
+		}}, out...)
+	}
+	if len(out) == 1 {
+		return out[0]
+	}
+	return newAnd(loc, out)
+}
+
+// CompileTyping replaces types by unary predicates: typed parameters
+// become preconditions, typed quantifiers are guarded, and the type
+// of every object and constant, supertypes included, becomes an
+// initial fact. The resulting domain and problem are untyped.
+func CompileTyping(d *models.Domain, pb *models.Problem) (*models.Domain, *models.Problem, error) {
+	if d == nil || pb == nil {
+		return nil, nil, fmt.Errorf("Failed to compile typing: domain or problem is nil")
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to compile typing: %v", err)
+	}
+	nd := d.Clone()
+	npb := pb.Clone()
+	used := map[string]bool{}
+	for _, p := range d.Predicates {
+		used[p.Name.Name] = true
+	}
+	tp := typePredicates{}
+	declare := func(t *models.Name) {
+		if t.Name == "object" {
+			return
+		}
+		if _, ok := tp[t.Name]; ok {
+			return
+		}
+		tp[t.Name] = uniqueName(t.Name, used)
+		loc := t.Location
+		if loc == nil || loc.Line == 0 {
+			loc = d.Name.Location
+		}
+		nd.Predicates = append(nd.Predicates, &models.Predicate{
+			Name: &models.Name{
+				Name:     tp[t.Name],
+				Location: loc,
+			},
+			Parameters: []*models.TypedEntry{
+				{
+					Name: &models.Name{
+						Name:     "?x",
+						Location: loc,
+					},
+				},
+			},
+		})
+	}
+	for _, t := range d.Types {
+		declare(t.TypedEntry.Name)
+		for _, p := range t.TypedEntry.Types {
+			declare(p.Name)
+		}
+	}
+	for _, t := range sortedKeys(u) {
+		declare(&models.Name{
+			Name: t,
+		})
+	}
+
+	for _, p := range nd.Predicates {
+		for _, te := range p.Parameters {
+			te.Types = nil
+		}
+	}
+	for _, f := range nd.Functions {
+		for _, te := range f.Params {
+			te.Types = nil
+		}
+	}
+	for _, a := range nd.Structures() {
+		a.Precondition = tp.guard(a.Params, a.Precondition)
+		a.Effect = tp.untypeFormula(a.Effect)
+	}
+	for _, t := range nd.Tasks {
+		for _, te := range t.Params {
+			te.Types = nil
+		}
+	}
+	for _, m := range nd.Methods {
+		m.Precondition = tp.guard(m.Params, m.Precondition)
+	}
+	for _, c := range nd.Constants {
+		c.Types = nil
+	}
+	nd.Types = nil
+	nd.Requirements = adjustRequirements(nd, ":typing")
+
+	for _, o := range npb.Objects {
+		o.Types = nil
+	}
+	for _, t := range sortedKeys(u) {
+		pred, ok := tp[t]
+		if !ok {
+			continue
+		}
+		for _, o := range u[t] {
+			npb.InitialConditions = append(npb.InitialConditions, newLiteral(pb.Name.Location, pred, false, false, []*models.Term{
+				{
+					Name: &models.Name{
+						Name:     o,
+						Location: pb.Name.Location,
+					},
+				},
+			}))
+		}
+	}
+	if npb.Htn != nil {
+		// The subtasks check the types of their arguments.
+		for _, te := range npb.Htn.Params {
+			te.Types = nil
+		}
+	}
+	npb.Goal = tp.untypeFormula(npb.Goal)
+	npb.Requirements = removeRequirements(npb.Requirements, ":typing")
+	npb.Requirements = adjustProblemRequirements(nd, npb)
+	return nd, npb, nil
+}
+
+func sortedKeys(u models.Universe) []string {
+	keys := make([]string, 0, len(u))
+	for k := range u {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
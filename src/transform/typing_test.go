@@ -0,0 +1,127 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+const typedDomain = `(define (domain trucks)
+  (:requirements :strips :typing :universal-preconditions)
+  (:types truck - vehicle vehicle place)
+  (:predicates (at ?v - vehicle ?p - place) (visited ?p - place))
+  (:action drive
+    :parameters (?t - truck ?from ?to - place)
+    :precondition (at ?t ?from)
+    :effect (and (not (at ?t ?from)) (at ?t ?to) (visited ?to))))
+`
+
+const typedProblem = `(define (problem trucks) (:domain trucks)
+  (:objects t1 - truck l1 l2 l3 - place)
+  (:init (at t1 l1) (visited l1))
+  (:goal (forall (?p - place) (visited ?p))))
+`
+
+func TestCompileTyping(t *testing.T) {
+	d, pb := parseTask(t, typedDomain, typedProblem)
+	nd, npb, err := CompileTyping(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nd.Types) != 0 || strings.Contains(requirements(nd.Requirements), ":typing") {
+		t.Errorf("got types %d and requirements %s", len(nd.Types), requirements(nd.Requirements))
+	}
+	preds := []string{}
+	for _, p := range nd.Predicates {
+		preds = append(preds, p.Name.Name)
+	}
+	if got := strings.Join(preds, " "); got != "at visited truck vehicle place" {
+		t.Errorf("got predicates %s", got)
+	}
+	want := "(and (truck ?t) (place ?from) (place ?to) (at ?t ?from))"
+	if got := text(nd.Actions[0].Precondition); got != want {
+		t.Errorf("got precondition %s, want %s", got, want)
+	}
+	want = "(forall (?p) (imply (and (place ?p)) (visited ?p)))"
+	if got := text(npb.Goal); got != want {
+		t.Errorf("got goal %s, want %s", got, want)
+	}
+	init := map[string]bool{}
+	for _, f := range npb.InitialConditions {
+		init[text(f)] = true
+	}
+	for _, fact := range []string{"(truck t1)", "(vehicle t1)", "(place l3)"} {
+		if !init[fact] {
+			t.Errorf("%s is not an initial fact", fact)
+		}
+	}
+	if init["(vehicle l1)"] {
+		t.Errorf("(vehicle l1) is an initial fact")
+	}
+	// The goal now has an implication, which the domain doesn't allow.
+	if got := requirements(npb.Requirements); got != ":disjunctive-preconditions" {
+		t.Errorf("got problem requirements %s, want :disjunctive-preconditions", got)
+	}
+	if planLength(t, d, pb) != 2 || planLength(t, nd, npb) != 2 {
+		t.Errorf("the untyped task has a different plan length")
+	}
+}
+
+const typedStructuresDomain = `(define (domain tanks)
+  (:requirements :strips :typing :time :hierarchy)
+  (:types tank)
+  (:predicates (open ?t - tank) (full ?t - tank))
+  (:process fill
+    :parameters (?t - tank)
+    :precondition (open ?t)
+    :effect (full ?t))
+  (:event overflow
+    :parameters (?t - tank)
+    :precondition (full ?t)
+    :effect (not (open ?t)))
+  (:action close
+    :parameters (?t - tank)
+    :precondition (open ?t)
+    :effect (not (open ?t)))
+  (:task shut :parameters (?t - tank))
+  (:method shut-open
+    :parameters (?t - tank)
+    :task (shut ?t)
+    :precondition (open ?t)
+    :subtasks (close ?t)))
+`
+
+const typedStructuresProblem = `(define (problem tanks) (:domain tanks)
+  (:objects t1 - tank)
+  (:htn :parameters (?t - tank) :subtasks (shut ?t))
+  (:init (open t1))
+  (:goal (full t1)))
+`
+
+func TestCompileTypingStructures(t *testing.T) {
+	d, pb := parseTask(t, typedStructuresDomain, typedStructuresProblem)
+	nd, npb, err := CompileTyping(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range nd.Structures() {
+		if len(a.Params[0].Types) != 0 {
+			t.Errorf("%s: parameter ?t is still typed", a.Name.Name)
+		}
+		if got := text(a.Precondition); !strings.HasPrefix(got, "(and (tank ?t)") {
+			t.Errorf("%s: got precondition %s", a.Name.Name, got)
+		}
+	}
+	if len(nd.Tasks[0].Params[0].Types) != 0 || len(nd.Methods[0].Params[0].Types) != 0 {
+		t.Errorf("the task or the method is still typed")
+	}
+	want := "(and (tank ?t) (open ?t))"
+	if got := text(nd.Methods[0].Precondition); got != want {
+		t.Errorf("got method precondition %s, want %s", got, want)
+	}
+	if len(npb.Htn.Params[0].Types) != 0 {
+		t.Errorf("the initial task network is still typed")
+	}
+	if strings.Contains(nd.ToString()+npb.ToString(), "- tank") {
+		t.Errorf("a type is left in\n%s\n%s", nd.ToString(), npb.ToString())
+	}
+}
@@ -0,0 +1,178 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/guilyx/go-pddl/src/models"
+)
+
+// Validator checks sequential plans against a task, the way VAL does.
+type Validator struct {
+	Domain   *models.Domain
+	Problem  *models.Problem
+	actions  map[string]*models.Action
+	universe models.Universe
+}
+
+// Report is the outcome of a validation. When the plan is invalid,
+// FailedStep is the index of the first faulty step, or len(plan.Steps)
+// when every step applies but the goal doesn't hold.
+type Report struct {
+	Valid       bool
+	FailedStep  int
+	Reason      string
+	Unsatisfied models.Formula
+	Bindings    map[string]string
+	FinalState  *models.State
+	HasMetric   bool
+	Metric      float64
+}
+
+func NewValidator(d *models.Domain, pb *models.Problem) (*Validator, error) {
+	if d == nil || pb == nil {
+		return nil, fmt.Errorf("Failed to create validator: domain or problem is nil")
+	}
+	u, err := models.NewUniverse(d, pb)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create validator: %v", err)
+	}
+	acts := map[string]*models.Action{}
+	for _, a := range d.Actions {
+		acts[a.Name.Name] = a
+	}
+	return &Validator{
+		Domain:   d,
+		Problem:  pb,
+		actions:  acts,
+		universe: u,
+	}, nil
+}
+
+// Validate simulates the plan from the initial state. Malformed
+// tasks return an error; plans that don't solve the task return a
+// report describing the first failure.
+func (v *Validator) Validate(plan *models.Plan) (*Report, error) {
+	if v == nil || plan == nil {
+		return nil, fmt.Errorf("Failed to validate plan: validator or plan is nil")
+	}
+	state, err := v.Problem.InitialState()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to validate plan: %v", err)
+	}
+	for i, step := range plan.Steps {
+		env := &models.Env{
+			State:    state,
+			Universe: v.universe,
+			Time:     float64(i),
+		}
+		act, ok := v.actions[step.Action.Name]
+		if !ok {
+			return v.fail(i, state, fmt.Sprintf("unknown action %s", step.Action.Name)), nil
+		}
+		if len(act.Params) != len(step.Terms) {
+			return v.fail(i, state, fmt.Sprintf("action %s expects %d arguments, got %d",
+				act.Name.Name, len(act.Params), len(step.Terms))), nil
+		}
+		b := models.Bindings{}
+		for j, p := range act.Params {
+			obj := step.Terms[j].Name.Name
+			if !v.universe.HasType(obj, p.Types) {
+				return v.fail(i, state, fmt.Sprintf("argument %s of %s is not of type %s",
+					obj, act.Name.Name, typeString(p.Types))), nil
+			}
+			b[p.Name.Name] = obj
+		}
+		ok, err = models.Evaluate(act.Precondition, env, b)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to validate plan at step %d: %v", i+1, err)
+		}
+		if !ok {
+			r := v.fail(i, state, "precondition of "+step.ToString()+" is not satisfied")
+			r.Unsatisfied, r.Bindings = models.Unsatisfied(act.Precondition, env, b)
+			return r, nil
+		}
+		d := &models.Delta{}
+		err = models.CollectEffects(act.Effect, env, b, d)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to validate plan at step %d: %v", i+1, err)
+		}
+		state, err = d.Apply(state)
+		if err != nil {
+			return v.fail(i, env.State, err.Error()), nil
+		}
+	}
+	env := &models.Env{
+		State:    state,
+		Universe: v.universe,
+		Time:     float64(len(plan.Steps)),
+	}
+	ok, err := models.Evaluate(v.Problem.Goal, env, models.Bindings{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to validate plan: %v", err)
+	}
+	if !ok {
+		r := v.fail(len(plan.Steps), state, "goal is not satisfied")
+		r.Unsatisfied, r.Bindings = models.Unsatisfied(v.Problem.Goal, env, models.Bindings{})
+		return r, nil
+	}
+	r := &Report{
+		Valid:      true,
+		FailedStep: -1,
+		FinalState: state,
+	}
+	if v.Problem.Metric != nil {
+		r.Metric, err = v.Problem.Metric.Expression.Value(env, models.Bindings{})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compute metric: %v", err)
+		}
+		r.HasMetric = true
+	}
+	return r, nil
+}
+
+func (v *Validator) fail(step int, s *models.State, reason string) *Report {
+	return &Report{
+		FailedStep: step,
+		Reason:     reason,
+		FinalState: s,
+	}
+}
+
+func (r *Report) ToString() string {
+	if r.Valid {
+		s := "Plan valid"
+		if r.HasMetric {
+			s += fmt.Sprintf(", metric value %g", r.Metric)
+		}
+		return s
+	}
+	s := fmt.Sprintf("Plan invalid at step %d: %s", r.FailedStep+1, r.Reason)
+	if r.Unsatisfied != nil {
+		s += "\nUnsatisfied: " + strings.TrimSpace(r.Unsatisfied.ToString(""))
+		if len(r.Bindings) > 0 {
+			vars := []string{}
+			for k, val := range r.Bindings {
+				vars = append(vars, k+"="+val)
+			}
+			sort.Strings(vars)
+			s += " with " + strings.Join(vars, ", ")
+		}
+	}
+	return s
+}
+
+func typeString(ts []*models.TypeName) string {
+	if len(ts) == 0 {
+		return "object"
+	}
+	names := make([]string, len(ts))
+	for i, t := range ts {
+		names[i] = t.Name.Name
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return "(either " + strings.Join(names, " ") + ")"
+}
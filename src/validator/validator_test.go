@@ -0,0 +1,114 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guilyx/go-pddl/src/config"
+	"github.com/guilyx/go-pddl/src/models"
+	"github.com/guilyx/go-pddl/src/parser"
+)
+
+const testDomain = `(define (domain blocks)
+  (:requirements :strips :typing :action-costs)
+  (:types block)
+  (:predicates (on ?x - block ?y - block) (ontable ?x - block)
+               (clear ?x - block) (handempty) (holding ?x - block))
+  (:functions (total-cost) - number)
+  (:action pick-up
+    :parameters (?x - block)
+    :precondition (and (clear ?x) (ontable ?x) (handempty))
+    :effect (and (not (ontable ?x)) (not (clear ?x)) (not (handempty)) (holding ?x)
+                 (increase (total-cost) 1)))
+  (:action stack
+    :parameters (?x - block ?y - block)
+    :precondition (and (holding ?x) (clear ?y))
+    :effect (and (not (holding ?x)) (not (clear ?y)) (clear ?x) (handempty) (on ?x ?y)
+                 (increase (total-cost) 2))))
+`
+
+const testProblem = `(define (problem two) (:domain blocks)
+  (:objects a b - block)
+  (:init (handempty) (ontable a) (ontable b) (clear a) (clear b) (= (total-cost) 0))
+  (:goal (on a b))
+  (:metric minimize (total-cost)))
+`
+
+func newValidator(t *testing.T) (*Validator, *models.Domain, *models.Problem) {
+	d, pb, err := parser.ParseTexts(&config.Config{}, testDomain, testProblem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewValidator(d, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, d, pb
+}
+
+func TestValidate(t *testing.T) {
+	v, d, pb := newValidator(t)
+	tests := []struct {
+		name   string
+		plan   string
+		valid  bool
+		step   int
+		reason string
+	}{
+		{"solution", "(pick-up a)\n(stack a b)\n", true, -1, ""},
+		{"upper-cased", "(PICK-UP A)\n(STACK A B)\n", true, -1, ""},
+		{"precondition", "(stack a b)\n", false, 0, "precondition of (stack a b) is not satisfied"},
+		{"goal", "(pick-up a)\n", false, 1, "goal is not satisfied"},
+		{"empty", "", false, 0, "goal is not satisfied"},
+	}
+	for _, test := range tests {
+		plan, perr := parser.ParsePlanText("plan", test.plan, d, pb)
+		if perr != nil {
+			t.Fatalf("%s: %v", test.name, perr.ToError())
+		}
+		r, err := v.Validate(plan)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if r.Valid != test.valid || r.FailedStep != test.step || r.Reason != test.reason {
+			t.Errorf("%s: got valid=%t step=%d reason=%q, want valid=%t step=%d reason=%q",
+				test.name, r.Valid, r.FailedStep, r.Reason, test.valid, test.step, test.reason)
+		}
+	}
+}
+
+func TestValidateMetric(t *testing.T) {
+	v, d, pb := newValidator(t)
+	plan, perr := parser.ParsePlanText("plan", "(pick-up a)\n(stack a b)\n", d, pb)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	r, err := v.Validate(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.HasMetric || r.Metric != 3 {
+		t.Errorf("got metric %g (%t), want 3", r.Metric, r.HasMetric)
+	}
+	if r.ToString() != "Plan valid, metric value 3" {
+		t.Errorf("got report %q", r.ToString())
+	}
+}
+
+func TestValidateUnsatisfied(t *testing.T) {
+	v, d, pb := newValidator(t)
+	plan, perr := parser.ParsePlanText("plan", "(pick-up a)\n(pick-up b)\n", d, pb)
+	if perr != nil {
+		t.Fatal(perr.ToError())
+	}
+	r, err := v.Validate(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Valid || r.FailedStep != 1 {
+		t.Fatalf("got valid=%t step=%d, want an invalid step 1", r.Valid, r.FailedStep)
+	}
+	if r.Unsatisfied == nil || !strings.Contains(r.ToString(), "Unsatisfied: (handempty)") {
+		t.Errorf("got report %q, want (handempty) unsatisfied", r.ToString())
+	}
+}